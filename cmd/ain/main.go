@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/jonaslu/ain/internal/app/ain"
 	"github.com/jonaslu/ain/internal/pkg/call"
+	"github.com/jonaslu/ain/internal/pkg/convert"
+	"github.com/jonaslu/ain/internal/pkg/data"
 	"github.com/jonaslu/ain/internal/pkg/disk"
 	"github.com/jonaslu/ain/internal/pkg/parse"
 )
@@ -36,6 +40,346 @@ func checkSignalRaisedAndExit(ctx context.Context, signalRaised os.Signal) {
 	}
 }
 
+// Captured values are written next to the template declaring
+// the [Capture] unless an .env-file is passed explicitly
+func writeCapturedValues(cmdParams *ain.CmdParams, captures []data.Capture, capturedValues []string) error {
+	stateFilePaths := []string{}
+	stateFileValues := map[string][][]string{}
+
+	for i, capture := range captures {
+		stateFilePath := disk.GetStateFilePath(capture.Filename)
+		if cmdParams.EnvFile != ".env" {
+			stateFilePath = cmdParams.EnvFile
+		}
+
+		if _, exists := stateFileValues[stateFilePath]; !exists {
+			stateFilePaths = append(stateFilePaths, stateFilePath)
+		}
+
+		stateFileValues[stateFilePath] = append(stateFileValues[stateFilePath], []string{capture.VarName, capturedValues[i]})
+	}
+
+	for _, stateFilePath := range stateFilePaths {
+		if err := disk.WriteEnvFileValues(stateFilePath, stateFileValues[stateFilePath]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatExplainedTemplates(explainedTemplates []parse.ExplainedTemplate, baseTemplateFileNames []string) string {
+	isBaseTemplate := map[string]bool{}
+	for _, baseTemplateFileName := range baseTemplateFileNames {
+		isBaseTemplate[baseTemplateFileName] = true
+	}
+
+	var explained strings.Builder
+
+	for _, explainedTemplate := range explainedTemplates {
+		explained.WriteString(explainedTemplate.Filename)
+
+		if explainedTemplate.IncludedBy != "" {
+			explained.WriteString("  (included by " + explainedTemplate.IncludedBy + ")")
+		} else if isBaseTemplate[explainedTemplate.Filename] {
+			explained.WriteString("  (base template)")
+		}
+
+		explained.WriteString("\n")
+	}
+
+	return explained.String()
+}
+
+// Reads the one file to import and writes the templates to the import folder
+func importFile(formatName, importFolder string, args []string, importer func([]byte) ([]data.ImportedFile, []data.NotImported, error)) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected one %s file to import, got %d", formatName, len(args))
+	}
+
+	fileBytes, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("could not read %s file %s: %v", formatName, args[0], err)
+	}
+
+	importedFiles, notImported, err := importer(fileBytes)
+	if err != nil {
+		return err
+	}
+
+	if err := disk.WriteImportedFiles(importFolder, importedFiles); err != nil {
+		return err
+	}
+
+	for _, importedFile := range importedFiles {
+		fmt.Println(filepath.Join(importFolder, importedFile.Filename))
+	}
+
+	if len(notImported) > 0 {
+		fmt.Fprintln(os.Stderr, "Not imported:")
+		for _, what := range notImported {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", filepath.Join(importFolder, what.Filename), what.What)
+		}
+	}
+
+	return nil
+}
+
+// A curl command is printed as a template, formats with
+// several requests are written as files to the import folder
+func importRequests(importFormat, importFolder string, args []string) error {
+	switch importFormat {
+	case "curl":
+		// The command can also be piped to ain
+		if len(args) == 0 {
+			curlCommand, err := disk.ReadPipedStdin()
+			if err != nil {
+				return err
+			}
+
+			args = []string{curlCommand}
+		}
+
+		template, err := convert.ImportCurl(args)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(template)
+		return nil
+
+	case "har":
+		return importFile("HAR", importFolder, args, convert.ImportHar)
+
+	case "postman":
+		return importFile("Postman collection", importFolder, args, convert.ImportPostman)
+
+	case "insomnia":
+		return importFile("Insomnia export", importFolder, args, convert.ImportInsomnia)
+	}
+
+	return fmt.Errorf("unknown import format: %s, expected curl, har, postman or insomnia", importFormat)
+}
+
+// The base template gets the same backends as a basic template from -b
+func generateOpenApiTemplates(specFilename, folder string) error {
+	return importFile("OpenAPI spec", folder, []string{specFilename}, func(specBytes []byte) ([]data.ImportedFile, []data.NotImported, error) {
+		generatedFiles, err := convert.GenerateOpenApiTemplates(specBytes, disk.GetBackendSections())
+		return generatedFiles, nil, err
+	})
+}
+
+// Captured values override any .env-file defaults
+func readEnvFiles(envFile string, templateFileNames []string) error {
+	for _, stateFilePath := range disk.GetStateFilePaths(templateFileNames) {
+		if err := disk.ReadEnvFile(stateFilePath, false); err != nil {
+			return err
+		}
+	}
+
+	return disk.ReadEnvFile(envFile, envFile != ".env")
+}
+
+// Every template in the folder and below is a request, or every request
+// in templates with several. Base templates and templates [Include]:d
+// by others are not requests of their own.
+func getFolderRequestNames(folder string) ([]string, string, error) {
+	templateFileNames := []string{}
+
+	err := filepath.Walk(folder, func(filePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		isHidden := strings.HasPrefix(fileInfo.Name(), ".") && filePath != folder
+		if fileInfo.IsDir() {
+			if isHidden {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !isHidden && fileInfo.Name() != "_base.ain" && filepath.Ext(filePath) == ".ain" {
+			templateFileNames = append(templateFileNames, filePath)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read folder %s: %v", folder, err)
+	}
+
+	requestNames := []string{}
+	for _, templateFileName := range templateFileNames {
+		fileRequestNames, fatal, err := parse.ListRequests([]string{templateFileName})
+		if err != nil || fatal != "" {
+			return nil, fatal, err
+		}
+
+		if len(fileRequestNames) == 0 {
+			fileRequestNames = []string{templateFileName}
+		}
+
+		requestNames = append(requestNames, fileRequestNames...)
+	}
+
+	isIncluded := map[string]bool{}
+	for _, requestName := range requestNames {
+		explainedTemplates, fatal, err := parse.ExplainTemplates([]string{requestName})
+		if err != nil || fatal != "" {
+			return nil, fatal, err
+		}
+
+		for _, explainedTemplate := range explainedTemplates {
+			if absFilename, err := filepath.Abs(explainedTemplate.Filename); err == nil && explainedTemplate.IncludedBy != "" {
+				isIncluded[absFilename] = true
+			}
+		}
+	}
+
+	folderRequestNames := []string{}
+	for _, requestName := range requestNames {
+		if absRequestName, err := filepath.Abs(requestName); err == nil && isIncluded[absRequestName] {
+			continue
+		}
+
+		folderRequestNames = append(folderRequestNames, requestName)
+	}
+
+	return folderRequestNames, "", nil
+}
+
+// Template files are exported as the one request they assemble
+// to, a folder as one request per template in it
+func exportRequests(cmdParams *ain.CmdParams, templateFileNames []string) (string, error) {
+	if cmdParams.ExportFormat != "har" && cmdParams.ExportFormat != "postman" {
+		return "", fmt.Errorf("unknown export format: %s, expected har or postman", cmdParams.ExportFormat)
+	}
+
+	requestTemplateFileNames := [][]string{}
+	requestNames := []string{}
+	collectionName := ""
+
+	if fileInfo, err := os.Stat(templateFileNames[0]); err == nil && fileInfo.IsDir() {
+		folder := templateFileNames[0]
+		if len(templateFileNames) > 1 {
+			return "", fmt.Errorf("expected one folder to export, got %d arguments", len(templateFileNames))
+		}
+
+		folderRequestNames, fatal, err := getFolderRequestNames(folder)
+		if err != nil || fatal != "" {
+			return fatal, err
+		}
+
+		if len(folderRequestNames) == 0 {
+			return "", fmt.Errorf("found no templates to export in %s", folder)
+		}
+
+		for _, folderRequestName := range folderRequestNames {
+			requestTemplateFileNames = append(requestTemplateFileNames, []string{folderRequestName})
+
+			// Named from the folder down, they become the folders of a collection
+			if relRequestName, err := filepath.Rel(folder, folderRequestName); err == nil {
+				folderRequestName = relRequestName
+			}
+
+			requestNames = append(requestNames, filepath.ToSlash(folderRequestName))
+		}
+
+		absFolder, err := filepath.Abs(folder)
+		if err != nil {
+			absFolder = folder
+		}
+
+		collectionName = filepath.Base(absFolder)
+	} else {
+		// Named after the last and most specific template
+		requestName := strings.TrimSuffix(filepath.Base(templateFileNames[len(templateFileNames)-1]), "!")
+		requestTemplateFileNames = append(requestTemplateFileNames, templateFileNames)
+		requestNames = append(requestNames, requestName)
+
+		templateFileName, _, _ := strings.Cut(requestName, "#")
+		collectionName = strings.TrimSuffix(templateFileName, ".ain")
+	}
+
+	allRequestTemplateFileNames := [][]string{}
+	envTemplateFileNames := []string{}
+
+	for _, localTemplateFileNames := range requestTemplateFileNames {
+		baseTemplateFileNames, err := disk.GetBaseTemplateFilenames(localTemplateFileNames)
+		if err != nil {
+			return "", err
+		}
+
+		allTemplateFileNames := append(baseTemplateFileNames, localTemplateFileNames...)
+		allRequestTemplateFileNames = append(allRequestTemplateFileNames, allTemplateFileNames)
+		envTemplateFileNames = append(envTemplateFileNames, allTemplateFileNames...)
+	}
+
+	if err := readEnvFiles(cmdParams.EnvFile, envTemplateFileNames); err != nil {
+		return "", err
+	}
+
+	assembleCtx := context.WithValue(context.Background(), data.PrintOnlyContextValueKey{}, true)
+	if cmdParams.NoExecCache {
+		assembleCtx = context.WithValue(assembleCtx, data.NoExecCacheContextValueKey{}, true)
+	}
+
+	exportedRequests := []data.ExportedRequest{}
+	for i, allTemplateFileNames := range allRequestTemplateFileNames {
+		_, cancelTimeout, backendInput, fatal, err := parse.Assemble(assembleCtx, allTemplateFileNames)
+		cancelTimeout()
+
+		if err != nil || fatal != "" {
+			return fatal, err
+		}
+
+		for _, warning := range backendInput.Warnings {
+			fmt.Fprintln(os.Stderr, warning)
+		}
+
+		exportedRequests = append(exportedRequests, data.ExportedRequest{Name: requestNames[i], BackendInput: backendInput})
+	}
+
+	var exportBytes []byte
+	var notExported []data.NotExported
+	var err error
+
+	if cmdParams.ExportFormat == "har" {
+		exportBytes, notExported, err = convert.ExportHar(exportedRequests, version, time.Now())
+	} else {
+		exportBytes, notExported, err = convert.ExportPostman(collectionName, exportedRequests)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Print(string(exportBytes))
+
+	if len(notExported) > 0 {
+		fmt.Fprintln(os.Stderr, "Not exported:")
+		for _, what := range notExported {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", what.Name, what.What)
+		}
+	}
+
+	return "", nil
+}
+
+// Output is streamed straight through when someone (or something)
+// is reading it as it arrives, i e a terminal or a pipe
+func isStdoutStreamable() bool {
+	fi, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&(os.ModeCharDevice|os.ModeNamedPipe) != 0
+}
+
 func main() {
 	cmdParams := ain.NewCmdParams()
 
@@ -48,6 +392,26 @@ func main() {
 		printErrorAndExit(err)
 	}
 
+	if cmdParams.ClearExecCache {
+		if err := disk.ClearExecCache(); err != nil {
+			printErrorAndExit(err)
+		}
+
+		return
+	}
+
+	if cmdParams.OpenApiSpec != "" && !cmdParams.GenerateEmptyTemplate {
+		printErrorAndExit(fmt.Errorf("flag --openapi is used together with -b"))
+	}
+
+	if cmdParams.GenerateEmptyTemplate && cmdParams.OpenApiSpec != "" {
+		if err := generateOpenApiTemplates(cmdParams.OpenApiSpec, cmdParams.ImportFolder); err != nil {
+			printErrorAndExit(err)
+		}
+
+		return
+	}
+
 	if cmdParams.GenerateEmptyTemplate {
 		if err := disk.GenerateEmptyTemplates(cmdParams.TemplateFileNames); err != nil {
 			printErrorAndExit(err)
@@ -56,16 +420,20 @@ func main() {
 		return
 	}
 
+	if cmdParams.ImportFormat != "" {
+		if err := importRequests(cmdParams.ImportFormat, cmdParams.ImportFolder, cmdParams.TemplateFileNames); err != nil {
+			printErrorAndExit(err)
+		}
+
+		return
+	}
+
 	for _, envVars := range cmdParams.EnvVars {
 		varName := envVars[0]
 		value := envVars[1]
 		os.Setenv(varName, value)
 	}
 
-	if err := disk.ReadEnvFile(cmdParams.EnvFile, cmdParams.EnvFile != ".env"); err != nil {
-		printErrorAndExit(err)
-	}
-
 	localTemplateFileNames, err := disk.GetTemplateFilenames(cmdParams.TemplateFileNames)
 	if err != nil {
 		printErrorAndExit(err)
@@ -75,6 +443,64 @@ func main() {
 		printErrorAndExit(fmt.Errorf("missing template file name(s)\n\nTry 'ain -h' for more information"))
 	}
 
+	if cmdParams.ExportFormat != "" {
+		fatal, err := exportRequests(cmdParams, localTemplateFileNames)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+
+		if fatal != "" {
+			fmt.Fprintln(os.Stderr, fatal)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	baseTemplateFileNames, err := disk.GetBaseTemplateFilenames(localTemplateFileNames)
+	if err != nil {
+		printErrorAndExit(err)
+	}
+
+	allTemplateFileNames := append(baseTemplateFileNames, localTemplateFileNames...)
+
+	if err := readEnvFiles(cmdParams.EnvFile, allTemplateFileNames); err != nil {
+		printErrorAndExit(err)
+	}
+
+	if cmdParams.ListRequests {
+		requestNames, fatal, err := parse.ListRequests(localTemplateFileNames)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+
+		if fatal != "" {
+			fmt.Fprintln(os.Stderr, fatal)
+			os.Exit(1)
+		}
+
+		for _, requestName := range requestNames {
+			fmt.Println(requestName)
+		}
+
+		return
+	}
+
+	if cmdParams.ExplainTemplates {
+		explainedTemplates, fatal, err := parse.ExplainTemplates(allTemplateFileNames)
+		if err != nil {
+			printErrorAndExit(err)
+		}
+
+		if fatal != "" {
+			fmt.Fprintln(os.Stderr, fatal)
+			os.Exit(1)
+		}
+
+		fmt.Print(formatExplainedTemplates(explainedTemplates, baseTemplateFileNames))
+		return
+	}
+
 	cancelCtx, cancel := context.WithCancel(context.Background())
 	var signalRaised os.Signal
 
@@ -86,7 +512,18 @@ func main() {
 		cancel()
 	}()
 
-	assembledCtx, backendInput, fatal, err := parse.Assemble(cancelCtx, localTemplateFileNames)
+	assembleCtx := cancelCtx
+	if cmdParams.NoExecCache {
+		assembleCtx = context.WithValue(assembleCtx, data.NoExecCacheContextValueKey{}, true)
+	}
+
+	if cmdParams.PrintCommand {
+		assembleCtx = context.WithValue(assembleCtx, data.PrintOnlyContextValueKey{}, true)
+	}
+
+	assembledCtx, cancelTimeout, backendInput, fatal, err := parse.Assemble(assembleCtx, allTemplateFileNames)
+	defer cancelTimeout()
+
 	if err != nil {
 		checkSignalRaisedAndExit(assembledCtx, signalRaised)
 
@@ -101,6 +538,10 @@ func main() {
 		os.Exit(1)
 	}
 
+	for _, warning := range backendInput.Warnings {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+
 	backendInput.PrintCommand = cmdParams.PrintCommand
 
 	call, err := call.Setup(backendInput)
@@ -116,7 +557,13 @@ func main() {
 
 	var errors []string
 	backendInput.LeaveTempFile = cmdParams.LeaveTmpFile
-	backendOutput, err := call.CallAsCmd(assembledCtx)
+
+	var backendOutput *data.BackendOutput
+	if !cmdParams.BufferOutput && isStdoutStreamable() {
+		backendOutput, err = call.CallAsStream(assembledCtx, os.Stdout, os.Stderr)
+	} else {
+		backendOutput, err = call.CallAsCmd(assembledCtx)
+	}
 
 	teardownErr := call.Teardown()
 	if teardownErr != nil {
@@ -141,7 +588,8 @@ func main() {
 
 	if backendOutput != nil {
 		// It's customary to print stderr first
-		// to get the users attention on the error
+		// to get the users attention on the error.
+		// Both are empty if the output was streamed.
 		fmt.Fprint(os.Stderr, backendOutput.Stderr)
 		fmt.Fprint(os.Stdout, backendOutput.Stdout)
 	}
@@ -152,5 +600,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err == nil && backendOutput.ExitCode == 0 && backendInput.CaptureResponse() {
+		if failedAssertions := parse.CheckAssertions(backendInput.Assertions, backendOutput.Response); failedAssertions != "" {
+			fmt.Fprintln(os.Stderr, failedAssertions)
+			os.Exit(1)
+		}
+
+		capturedValues, failedCaptures := parse.CaptureValues(backendInput.Captures, backendOutput.Response)
+		if failedCaptures != "" {
+			fmt.Fprintln(os.Stderr, failedCaptures)
+			os.Exit(1)
+		}
+
+		if err := writeCapturedValues(cmdParams, backendInput.Captures, capturedValues); err != nil {
+			printErrorAndExit(err)
+		}
+	}
+
 	os.Exit(backendOutput.ExitCode)
 }
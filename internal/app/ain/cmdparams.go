@@ -23,8 +23,8 @@ Project home page: https://github.com/jonaslu/ain`
 	}
 
 	fmt.Fprintf(w, "\nARGUMENTS:\n")
-	fmt.Fprintf(w, "  <template.ain>[!]       One or more template files to process. Required\n")
-	fmt.Fprintf(w, "  "+varsFlagStr+" VAR=VALUE [...]  Values for environment variables, set after <template.ain> file(s)\n")
+	fmt.Fprintf(w, "  <template.ain>[#name][!]  One or more template files to process. Required\n")
+	fmt.Fprintf(w, "  "+varsFlagStr+" VAR=VALUE [...]    Values for environment variables, set after <template.ain> file(s)\n")
 }
 
 type flagConsumer func([]string) (found bool, restArgs []string, error error)
@@ -94,8 +94,12 @@ func makeStringFlag(flagName, usage string, val *string) flag {
 }
 
 func NewCmdParams() *CmdParams {
-	var leaveTmpFile, printCommand, showVersion, generateEmptyTemplate, showHelp bool
+	var leaveTmpFile, printCommand, showVersion, generateEmptyTemplate, showHelp, bufferOutput, noExecCache, clearExecCache, listRequests, explainTemplates bool
 	envFile := ".env"
+	importFormat := ""
+	exportFormat := ""
+	importFolder := "."
+	openApiSpec := ""
 
 	flags := []flag{}
 
@@ -103,9 +107,18 @@ func NewCmdParams() *CmdParams {
 	restArgs := os.Args[1:]
 
 	flags = append(flags, makeBoolFlag("-p", "Print command to the terminal instead of executing", &printCommand))
+	flags = append(flags, makeStringFlag("--export", "Print the request(s) in the given format (har, postman) instead of executing", &exportFormat))
 	flags = append(flags, makeStringFlag("-e", "Path to .env file", &envFile))
 	flags = append(flags, makeBoolFlag("-l", "Leave any body-files", &leaveTmpFile))
+	flags = append(flags, makeBoolFlag("-w", "Wait for the backend to exit before printing its output", &bufferOutput))
+	flags = append(flags, makeBoolFlag("--list", "List the [Request <name>] blocks in the template file(s)", &listRequests))
+	flags = append(flags, makeBoolFlag("--explain", "List the template files in the order they are assembled and exit", &explainTemplates))
+	flags = append(flags, makeBoolFlag("--no-cache", "Run all executables and fetch oauth2 tokens, ignoring any cache", &noExecCache))
+	flags = append(flags, makeBoolFlag("--clear-cache", "Clear cached executable output and oauth2 tokens and exit", &clearExecCache))
 	flags = append(flags, makeBoolFlag("-b", "Generate basic template files(s)", &generateEmptyTemplate))
+	flags = append(flags, makeStringFlag("--openapi", "Generate templates from an OpenAPI 3 spec, used with -b", &openApiSpec))
+	flags = append(flags, makeStringFlag("-i", "Import requests in the given format (curl, har, postman, insomnia) and exit", &importFormat))
+	flags = append(flags, makeStringFlag("--out", "Folder to write imported or generated templates to", &importFolder))
 	flags = append(flags, makeBoolFlag("-v", "Show version and exit", &showVersion))
 	flags = append(flags, makeBoolFlag("-h", "Show help and exit", &showHelp))
 
@@ -157,10 +170,19 @@ func NewCmdParams() *CmdParams {
 	return &CmdParams{
 		restArgs:              restArgs,
 		LeaveTmpFile:          leaveTmpFile,
+		BufferOutput:          bufferOutput,
+		NoExecCache:           noExecCache,
+		ClearExecCache:        clearExecCache,
+		ListRequests:          listRequests,
+		ExplainTemplates:      explainTemplates,
 		PrintCommand:          printCommand,
 		ShowVersion:           showVersion,
 		GenerateEmptyTemplate: generateEmptyTemplate,
 		EnvFile:               envFile,
+		ImportFormat:          importFormat,
+		ExportFormat:          exportFormat,
+		ImportFolder:          importFolder,
+		OpenApiSpec:           openApiSpec,
 	}
 }
 
@@ -200,10 +222,19 @@ type CmdParams struct {
 	restArgs []string
 
 	LeaveTmpFile          bool
+	BufferOutput          bool
+	NoExecCache           bool
+	ClearExecCache        bool
+	ListRequests          bool
+	ExplainTemplates      bool
 	PrintCommand          bool
 	ShowVersion           bool
 	GenerateEmptyTemplate bool
 	EnvFile               string
+	ImportFormat          string
+	ExportFormat          string
+	ImportFolder          string
+	OpenApiSpec           string
 	EnvVars               [][]string
 	TemplateFileNames     []string
 }
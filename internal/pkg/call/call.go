@@ -3,7 +3,10 @@ package call
 import (
 	"bytes"
 	"context"
+	"io"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/jonaslu/ain/internal/pkg/data"
 	"github.com/pkg/errors"
@@ -12,28 +15,55 @@ import (
 type backendConstructor struct {
 	BinaryName  string
 	constructor func(*data.BackendInput, string) backend
+	// If the backend can report the response status and headers
+	reportsResponseHead bool
+	// Digest auth needs a round-trip to the server for the challenge
+	supportsDigestAuth bool
+	// If not, [Multipart] is sent as a generated body
+	hasMultipartFlags bool
 }
 
 var ValidBackends = map[string]backendConstructor{
 	"curl": {
-		BinaryName:  "curl",
-		constructor: newCurlBackend,
+		BinaryName:          "curl",
+		constructor:         newCurlBackend,
+		reportsResponseHead: true,
+		supportsDigestAuth:  true,
+		hasMultipartFlags:   true,
 	},
 	"httpie": {
-		BinaryName:  "http",
-		constructor: newHttpieBackend,
+		BinaryName:         "http",
+		constructor:        newHttpieBackend,
+		supportsDigestAuth: true,
+		hasMultipartFlags:  true,
 	},
 	"wget": {
-		BinaryName:  "wget",
-		constructor: newWgetBackend,
+		BinaryName:         "wget",
+		constructor:        newWgetBackend,
+		supportsDigestAuth: true,
+	},
+	"native": {
+		// Needs no binary, the call is made with go:s net/http
+		BinaryName:          "",
+		constructor:         newNativeBackend,
+		reportsResponseHead: true,
 	},
 }
 
 type backend interface {
-	getAsCmd(context.Context) *exec.Cmd
+	runAsCmd(ctx context.Context, stdout, stderr io.Writer) (*data.BackendOutput, error)
 	getAsString() string
 }
 
+func runCmd(cmd *exec.Cmd, stdout, stderr io.Writer) (*data.BackendOutput, error) {
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+
+	return &data.BackendOutput{ExitCode: cmd.ProcessState.ExitCode()}, err
+}
+
 func getBackend(backendInput *data.BackendInput) (backend, error) {
 	requestedBackend := backendInput.Backend
 
@@ -52,6 +82,52 @@ func ValidBackend(backendName string) bool {
 	return false
 }
 
+func ReportsResponseHead(backendName string) bool {
+	return ValidBackends[backendName].reportsResponseHead
+}
+
+// Returns the backend option putting more than the response
+// body on stdout, so the body cannot be read from it
+func GetNonBodyOutputOption(backendName string, backendOptions [][]string) string {
+	if backendName == "httpie" {
+		return getHttpieNonBodyOutputOption(backendOptions)
+	}
+
+	return ""
+}
+
+func SupportsAuthScheme(backendName, scheme string) bool {
+	if scheme == data.DigestAuthScheme {
+		return ValidBackends[backendName].supportsDigestAuth
+	}
+
+	return true
+}
+
+// Printed in place of [Auth] secrets, a printed command
+// is shared and ends up in the shell history
+const (
+	printedPassword = "<password>"
+	printedToken    = "<token>"
+)
+
+func getPrintedAuth(auth *data.Auth) *data.Auth {
+	if auth == nil {
+		return nil
+	}
+
+	printedAuth := *auth
+	if printedAuth.Password != "" {
+		printedAuth.Password = printedPassword
+	}
+
+	if printedAuth.Token != "" {
+		printedAuth.Token = printedToken
+	}
+
+	return &printedAuth
+}
+
 type Call struct {
 	backendInput        *data.BackendInput
 	backend             backend
@@ -68,10 +144,23 @@ func Setup(backendInput *data.BackendInput) (*Call, error) {
 
 	call.backend = backend
 
+	if !ValidBackends[backendInput.Backend].hasMultipartFlags {
+		if err := backendInput.SetMultipartBody(); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := backendInput.CreateBodyTempFile(); err != nil {
 		return nil, err
 	}
 
+	if auth := backendInput.Auth; auth != nil && auth.AwsSigV4 != nil {
+		if err := signAwsSigV4(backendInput, time.Now()); err != nil {
+			_ = backendInput.RemoveBodyTempFile(true)
+			return nil, err
+		}
+	}
+
 	return &call, nil
 }
 
@@ -80,20 +169,34 @@ func (c *Call) CallAsString() string {
 }
 
 func (c *Call) CallAsCmd(ctx context.Context) (*data.BackendOutput, error) {
-	backendCmd := c.backend.getAsCmd(ctx)
-
 	var stdout, stderr bytes.Buffer
-	backendCmd.Stdout = &stdout
-	backendCmd.Stderr = &stderr
 
-	err := backendCmd.Run()
+	backendOutput, err := c.CallAsStream(ctx, &stdout, &stderr)
+
+	backendOutput.Stderr = stderr.String()
+	backendOutput.Stdout = stdout.String()
+
+	return backendOutput, err
+}
+
+// CallAsStream copies the backend output to stdout and stderr as it
+// arrives instead of collecting it in the returned BackendOutput
+func (c *Call) CallAsStream(ctx context.Context, stdout, stderr io.Writer) (*data.BackendOutput, error) {
+	var responseBody bytes.Buffer
+	if c.backendInput.CaptureResponse() {
+		stdout = io.MultiWriter(stdout, &responseBody)
+	}
+
+	backendOutput, err := c.backend.runAsCmd(ctx, stdout, stderr)
 
 	c.forceRemoveTempFile = err != nil
 
-	backendOutput := &data.BackendOutput{
-		Stderr:   stderr.String(),
-		Stdout:   stdout.String(),
-		ExitCode: backendCmd.ProcessState.ExitCode(),
+	if c.backendInput.CaptureResponse() {
+		if backendOutput.Response == nil {
+			backendOutput.Response = &data.Response{}
+		}
+
+		backendOutput.Response.Body = strings.TrimPrefix(responseBody.String(), backendOutput.Response.Head)
 	}
 
 	if ctx.Err() == context.DeadlineExceeded {
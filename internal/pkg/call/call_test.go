@@ -0,0 +1,59 @@
+package call
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_CallAsCmdLeavesHeadOutOfBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	hostUrl, _ := url.Parse(server.URL)
+
+	for _, backendName := range []string{"curl", "native"} {
+		if binaryName := ValidBackends[backendName].BinaryName; binaryName != "" {
+			if _, err := exec.LookPath(binaryName); err != nil {
+				t.Logf("Skipping %s, not installed", backendName)
+				continue
+			}
+		}
+
+		backendInput := &data.BackendInput{
+			Host:           hostUrl,
+			Backend:        backendName,
+			BackendOptions: [][]string{{"-s"}, {"-i"}},
+			Assertions:     []data.Assertion{{Selector: data.ResponseSelector{Subject: data.BodySubject}}},
+		}
+
+		if backendName == "native" {
+			backendInput.BackendOptions = [][]string{{"-i"}}
+		}
+
+		call, err := Setup(backendInput)
+		if err != nil {
+			t.Fatalf("Backend: %s. Unexpected error: %v", backendName, err)
+		}
+
+		backendOutput, err := call.CallAsCmd(context.Background())
+		_ = call.Teardown()
+
+		if err != nil {
+			t.Fatalf("Backend: %s. Unexpected error: %v", backendName, err)
+		}
+
+		// The head is printed with -i but is not part of the body
+		if backendOutput.Response.Body != `{"id": 1}` || backendOutput.Stdout == backendOutput.Response.Body {
+			t.Errorf("Backend: %s. Unexpected body: %q, stdout: %q", backendName, backendOutput.Response.Body, backendOutput.Stdout)
+		}
+	}
+}
@@ -2,11 +2,16 @@ package call
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/jonaslu/ain/internal/pkg/data"
 	"github.com/jonaslu/ain/internal/pkg/utils"
+	"github.com/pkg/errors"
 )
 
 type curl struct {
@@ -48,14 +53,78 @@ func (curl *curl) getMethodArgument(escape bool) []string {
 	return []string{}
 }
 
+// Escaped arguments are printed, so any secret is left out
+func (curl *curl) getAuthArguments(escape bool) []string {
+	auth := curl.backendInput.Auth
+	if escape {
+		auth = getPrintedAuth(auth)
+	}
+
+	if auth == nil {
+		return []string{}
+	}
+
+	maybeEscape := func(arg string) string {
+		if escape {
+			return utils.EscapeForShell(arg)
+		}
+
+		return arg
+	}
+
+	switch auth.Scheme {
+	case data.BasicAuthScheme:
+		return []string{"-u", maybeEscape(auth.User + ":" + auth.Password)}
+	case data.DigestAuthScheme:
+		return []string{"--digest", "-u", maybeEscape(auth.User + ":" + auth.Password)}
+	case data.BearerAuthScheme:
+		return []string{"--oauth2-bearer", maybeEscape(auth.Token)}
+	}
+
+	return []string{}
+}
+
 func (curl *curl) getBodyArgument() []string {
 	if curl.backendInput.TempFileName != "" {
+		// A [BodyFile] or a generated multipart body (when printed for the
+		// native backend) must not have its line-breaks stripped as -d does.
+		// Neither a signed body, the signature is of the temp-file as is.
+		auth := curl.backendInput.Auth
+		if curl.backendInput.BodyFileName != "" || len(curl.backendInput.Multipart) > 0 || (auth != nil && auth.AwsSigV4 != nil) {
+			return []string{"--data-binary", "@" + curl.backendInput.TempFileName}
+		}
+
 		return []string{"-d", "@" + curl.backendInput.TempFileName}
 	}
 
 	return []string{}
 }
 
+func (curl *curl) getMultipartArguments(escape bool) [][]string {
+	args := [][]string{}
+	if curl.backendInput.TempFileName != "" {
+		return args
+	}
+
+	for _, field := range curl.backendInput.Multipart {
+		flag, value := "--form-string", field.Name+"="+field.Value
+		if field.Filename != "" {
+			flag, value = "-F", field.Name+"=@"+field.Filename
+			if field.ContentType != "" {
+				value += ";type=" + field.ContentType
+			}
+		}
+
+		if escape {
+			value = utils.EscapeForShell(value)
+		}
+
+		args = append(args, []string{flag, value})
+	}
+
+	return args
+}
+
 func (curl *curl) getAsCmd(ctx context.Context) *exec.Cmd {
 	args := []string{}
 	for _, backendOpt := range curl.backendInput.BackendOptions {
@@ -63,16 +132,83 @@ func (curl *curl) getAsCmd(ctx context.Context) *exec.Cmd {
 	}
 
 	args = append(args, curl.getMethodArgument(false)...)
+	args = append(args, curl.getAuthArguments(false)...)
 	for _, headerArgs := range curl.getHeaderArguments(false) {
 		args = append(args, headerArgs...)
 	}
 
 	args = append(args, curl.getBodyArgument()...)
+	for _, multipartArgs := range curl.getMultipartArguments(false) {
+		args = append(args, multipartArgs...)
+	}
+
 	args = append(args, curl.backendInput.Host.String())
 
 	return exec.CommandContext(ctx, curl.binaryName, args...)
 }
 
+// The last response wins if curl follows redirects
+// or gets a 100 Continue before the real response
+func parseDumpedHeaders(dumpedHeaders string) (int, http.Header) {
+	statusCode := 0
+	headers := http.Header{}
+
+	for _, line := range strings.Split(strings.ReplaceAll(dumpedHeaders, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "HTTP/") {
+			statusCode = 0
+			headers = http.Header{}
+
+			if statusLineFields := strings.Fields(line); len(statusLineFields) > 1 {
+				statusCode, _ = strconv.Atoi(statusLineFields[1])
+			}
+
+			continue
+		}
+
+		if headerName, headerValue, found := strings.Cut(line, ":"); found {
+			headers.Add(strings.TrimSpace(headerName), strings.TrimSpace(headerValue))
+		}
+	}
+
+	return statusCode, headers
+}
+
+func (curl *curl) runAsCmd(ctx context.Context, stdout, stderr io.Writer) (*data.BackendOutput, error) {
+	if !curl.backendInput.CaptureResponse() {
+		return runCmd(curl.getAsCmd(ctx), stdout, stderr)
+	}
+
+	headersFile, err := os.CreateTemp("", "ain-headers")
+	if err != nil {
+		return &data.BackendOutput{ExitCode: 1}, errors.Wrap(err, "could not create tempfile for response headers")
+	}
+
+	_ = headersFile.Close()
+	defer os.Remove(headersFile.Name())
+
+	curlCmd := curl.getAsCmd(ctx)
+	curlCmd.Args = append(curlCmd.Args, "-D", headersFile.Name())
+
+	backendOutput, err := runCmd(curlCmd, stdout, stderr)
+	if err != nil {
+		return backendOutput, err
+	}
+
+	dumpedHeaders, err := os.ReadFile(headersFile.Name())
+	if err != nil {
+		return backendOutput, errors.Wrap(err, "could not read response headers")
+	}
+
+	statusCode, headers := parseDumpedHeaders(string(dumpedHeaders))
+	backendOutput.Response = &data.Response{
+		StatusCode: statusCode,
+		Headers:    headers,
+		Head:       string(dumpedHeaders),
+	}
+
+	return backendOutput, nil
+}
+
 func (curl *curl) getAsString() string {
 	args := [][]string{}
 
@@ -85,9 +221,11 @@ func (curl *curl) getAsString() string {
 	}
 
 	args = append(args, curl.getMethodArgument(true))
+	args = append(args, curl.getAuthArguments(true))
 	args = append(args, curl.getHeaderArguments(true)...)
 
 	args = append(args, curl.getBodyArgument())
+	args = append(args, curl.getMultipartArguments(true)...)
 	args = append(args, []string{
 		utils.EscapeForShell(curl.backendInput.Host.String()),
 	})
@@ -0,0 +1,81 @@
+package call
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_parseDumpedHeaders(t *testing.T) {
+	dumpedHeaders := "HTTP/1.1 301 Moved Permanently\r\n" +
+		"Location: /new\r\n" +
+		"\r\n" +
+		"HTTP/2 201 \r\n" +
+		"content-type: application/json\r\n" +
+		"set-cookie: a=1\r\n" +
+		"set-cookie: b=2\r\n" +
+		"\r\n"
+
+	statusCode, headers := parseDumpedHeaders(dumpedHeaders)
+
+	if statusCode != 201 {
+		t.Errorf("Expected status 201, got: %d", statusCode)
+	}
+
+	if headers.Get("Location") != "" {
+		t.Errorf("Expected headers from the redirect to be dropped, got: %v", headers)
+	}
+
+	if headers.Get("Content-Type") != "application/json" || len(headers.Values("Set-Cookie")) != 2 {
+		t.Errorf("Unexpected headers: %v", headers)
+	}
+}
+
+func Test_curlSendsSignedAwsSigV4Body(t *testing.T) {
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl not installed")
+	}
+
+	var sentBodyHash, signedBodyHash string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sentBody, _ := io.ReadAll(r.Body)
+		sentBodyHash = sha256Hex(sentBody)
+		signedBodyHash = r.Header.Get("X-Amz-Content-Sha256")
+	}))
+	defer server.Close()
+
+	hostUrl, _ := url.Parse(server.URL)
+	awsSigV4 := *sigV4TestCredentials
+	backendInput := &data.BackendInput{
+		Host:    hostUrl,
+		Body:    []string{"{", `  "some": "json"`, "}"},
+		Backend: "curl",
+		Auth:    &data.Auth{Scheme: data.AwsSigV4AuthScheme, AwsSigV4: &awsSigV4},
+	}
+
+	if err := backendInput.CreateBodyTempFile(); err != nil {
+		t.Fatalf("Could not create body temp-file: %v", err)
+	}
+	defer os.Remove(backendInput.TempFileName)
+
+	if err := signAwsSigV4(backendInput, time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	curlBackend := &curl{backendInput: backendInput, binaryName: "curl"}
+	if output, err := curlBackend.getAsCmd(context.Background()).CombinedOutput(); err != nil {
+		t.Fatalf("Could not run curl: %v %s", err, output)
+	}
+
+	if sentBodyHash != signedBodyHash {
+		t.Errorf("Sent body hash %s does not match signed hash %s", sentBodyHash, signedBodyHash)
+	}
+}
@@ -2,6 +2,7 @@ package call
 
 import (
 	"context"
+	"io"
 	"os/exec"
 	"strings"
 
@@ -14,6 +15,40 @@ type httpie struct {
 	binaryName   string
 }
 
+// Make httpie print the request or the response head to stdout
+var httpieNonBodyOutputOptions = []string{"-v", "--verbose", "-h", "--headers", "--all", "-m", "--meta"}
+
+func getHttpieNonBodyOutputOption(backendOptions [][]string) string {
+	for _, backendOptionLine := range backendOptions {
+		for i, backendOption := range backendOptionLine {
+			optionName, printed, hasValue := strings.Cut(backendOption, "=")
+
+			for _, nonBodyOutputOption := range httpieNonBodyOutputOptions {
+				if optionName == nonBodyOutputOption {
+					return backendOption
+				}
+			}
+
+			if optionName != "--print" && optionName != "-p" {
+				continue
+			}
+
+			printOption := backendOption
+			if !hasValue && i+1 < len(backendOptionLine) {
+				printed = backendOptionLine[i+1]
+				printOption += " " + printed
+			}
+
+			// b is the response body only
+			if printed != "b" {
+				return printOption
+			}
+		}
+	}
+
+	return ""
+}
+
 func prependIgnoreStdin(backendInput *data.BackendInput) {
 	var foundIgnoreStdin bool
 
@@ -43,6 +78,29 @@ func (httpie *httpie) getMethodArgument() string {
 	return strings.ToUpper(httpie.backendInput.Method)
 }
 
+// Printed arguments leave out any secret
+func (httpie *httpie) getAuthArguments(printed bool) []string {
+	auth := httpie.backendInput.Auth
+	if printed {
+		auth = getPrintedAuth(auth)
+	}
+
+	if auth == nil {
+		return []string{}
+	}
+
+	switch auth.Scheme {
+	case data.BasicAuthScheme:
+		return []string{"-a", auth.User + ":" + auth.Password}
+	case data.DigestAuthScheme:
+		return []string{"-A", "digest", "-a", auth.User + ":" + auth.Password}
+	case data.BearerAuthScheme:
+		return []string{"-A", "bearer", "-a", auth.Token}
+	}
+
+	return []string{}
+}
+
 func (httpie *httpie) getBodyArgument() []string {
 	if httpie.backendInput.TempFileName != "" {
 		return []string{"@" + httpie.backendInput.TempFileName}
@@ -51,12 +109,38 @@ func (httpie *httpie) getBodyArgument() []string {
 	return []string{}
 }
 
+// Files are field@path items, values field=value
+func (httpie *httpie) getMultipartArguments() []string {
+	args := []string{}
+	for _, field := range httpie.backendInput.Multipart {
+		if field.Filename == "" {
+			args = append(args, field.Name+"="+field.Value)
+			continue
+		}
+
+		fileArg := field.Name + "@" + field.Filename
+		if field.ContentType != "" {
+			fileArg += ";type=" + field.ContentType
+		}
+
+		args = append(args, fileArg)
+	}
+
+	return args
+}
+
 func (httpie *httpie) getAsCmd(ctx context.Context) *exec.Cmd {
 	args := []string{}
 	for _, backendOpt := range httpie.backendInput.BackendOptions {
 		args = append(args, backendOpt...)
 	}
 
+	args = append(args, httpie.getAuthArguments(false)...)
+
+	if len(httpie.backendInput.Multipart) > 0 {
+		args = append(args, "--multipart")
+	}
+
 	if httpie.backendInput.Method != "" {
 		args = append(args, httpie.getMethodArgument())
 	}
@@ -64,11 +148,16 @@ func (httpie *httpie) getAsCmd(ctx context.Context) *exec.Cmd {
 	args = append(args, httpie.backendInput.Host.String())
 	args = append(args, httpie.backendInput.Headers...)
 	args = append(args, httpie.getBodyArgument()...)
+	args = append(args, httpie.getMultipartArguments()...)
 
 	httpCmd := exec.CommandContext(ctx, httpie.binaryName, args...)
 	return httpCmd
 }
 
+func (httpie *httpie) runAsCmd(ctx context.Context, stdout, stderr io.Writer) (*data.BackendOutput, error) {
+	return runCmd(httpie.getAsCmd(ctx), stdout, stderr)
+}
+
 func (httpie *httpie) getAsString() string {
 	args := [][]string{}
 	for _, optionLine := range httpie.backendInput.BackendOptions {
@@ -79,6 +168,16 @@ func (httpie *httpie) getAsString() string {
 		args = append(args, lineArguments)
 	}
 
+	authArguments := []string{}
+	for _, authArgument := range httpie.getAuthArguments(true) {
+		authArguments = append(authArguments, utils.EscapeForShell(authArgument))
+	}
+	args = append(args, authArguments)
+
+	if len(httpie.backendInput.Multipart) > 0 {
+		args = append(args, []string{"--multipart"})
+	}
+
 	if httpie.backendInput.Method != "" {
 		args = append(args, []string{utils.EscapeForShell(httpie.getMethodArgument())})
 	}
@@ -91,6 +190,10 @@ func (httpie *httpie) getAsString() string {
 
 	args = append(args, httpie.getBodyArgument())
 
+	for _, multipartArgument := range httpie.getMultipartArguments() {
+		args = append(args, []string{utils.EscapeForShell(multipartArgument)})
+	}
+
 	output := httpie.binaryName + " " + utils.PrettyPrintStringsForShell(args)
 
 	return output
@@ -0,0 +1,222 @@
+package call
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/pkg/errors"
+)
+
+// The native backend accepts a small subset of the curl options,
+// named as in curl so the printed curl command stays equivalent.
+const (
+	nativeIncludeOption  = "include"
+	nativeInsecureOption = "insecure"
+	nativeLocationOption = "location"
+	nativeNoopOption     = "noop"
+)
+
+var nativeBackendOptions = map[string]string{
+	"-i":           nativeIncludeOption,
+	"--include":    nativeIncludeOption,
+	"-k":           nativeInsecureOption,
+	"--insecure":   nativeInsecureOption,
+	"-L":           nativeLocationOption,
+	"--location":   nativeLocationOption,
+	"-s":           nativeNoopOption,
+	"-S":           nativeNoopOption,
+	"-sS":          nativeNoopOption,
+	"-Ss":          nativeNoopOption,
+	"--silent":     nativeNoopOption,
+	"--show-error": nativeNoopOption,
+}
+
+type native struct {
+	backendInput *data.BackendInput
+}
+
+func newNativeBackend(backendInput *data.BackendInput, _ string) backend {
+	return &native{
+		backendInput: backendInput,
+	}
+}
+
+func (native *native) getOptions() (map[string]bool, error) {
+	options := map[string]bool{}
+
+	for _, backendOptionLine := range native.backendInput.BackendOptions {
+		for _, backendOption := range backendOptionLine {
+			option, exists := nativeBackendOptions[backendOption]
+			if !exists {
+				return nil, errors.Errorf("Backend native does not support the option: %s", backendOption)
+			}
+
+			options[option] = true
+		}
+	}
+
+	return options, nil
+}
+
+func (native *native) getMethod() string {
+	if native.backendInput.Method != "" {
+		return strings.ToUpper(native.backendInput.Method)
+	}
+
+	// Same as curl, a body without a method is a POST
+	if native.backendInput.TempFileName != "" {
+		return http.MethodPost
+	}
+
+	return http.MethodGet
+}
+
+func (native *native) getRequest(ctx context.Context) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, native.getMethod(), native.backendInput.Host.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, header := range native.backendInput.Headers {
+		headerName, headerValue, found := strings.Cut(header, ":")
+		if !found {
+			return nil, errors.Errorf("Malformed header, missing colon: %s", header)
+		}
+
+		headerName = strings.TrimSpace(headerName)
+		headerValue = strings.TrimSpace(headerValue)
+
+		if strings.EqualFold(headerName, "Host") {
+			req.Host = headerValue
+			continue
+		}
+
+		req.Header.Add(headerName, headerValue)
+	}
+
+	if auth := native.backendInput.Auth; auth != nil {
+		switch auth.Scheme {
+		case data.BasicAuthScheme:
+			req.SetBasicAuth(auth.User, auth.Password)
+		case data.BearerAuthScheme:
+			req.Header.Set("Authorization", "Bearer "+auth.Token)
+		case data.AwsSigV4AuthScheme:
+			// Already signed into the headers
+		default:
+			return nil, errors.Errorf("Backend native does not support %s auth", auth.Scheme)
+		}
+	}
+
+	// Opened last so no error above leaves the file open
+	if native.backendInput.TempFileName != "" {
+		bodyFile, err := os.Open(native.backendInput.TempFileName)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not open file with [Body] contents")
+		}
+
+		stat, err := bodyFile.Stat()
+		if err != nil {
+			_ = bodyFile.Close()
+			return nil, errors.Wrap(err, "could not stat file with [Body] contents")
+		}
+
+		req.Body = bodyFile
+		req.ContentLength = stat.Size()
+
+		// Resent when following a 307 or 308 redirect, same as curl -L
+		req.GetBody = func() (io.ReadCloser, error) {
+			return os.Open(native.backendInput.TempFileName)
+		}
+
+		// Same as curl, default content type for a body
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+
+	return req, nil
+}
+
+func (native *native) getClient(options map[string]bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options[nativeInsecureOption] {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	client := &http.Client{Transport: transport}
+
+	if !options[nativeLocationOption] {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return client
+}
+
+func writeResponseHead(resp *http.Response, stdout io.Writer) error {
+	if _, err := fmt.Fprintf(stdout, "%s %s\r\n", resp.Proto, resp.Status); err != nil {
+		return err
+	}
+
+	if err := resp.Header.Write(stdout); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(stdout, "\r\n")
+	return err
+}
+
+func (native *native) runAsCmd(ctx context.Context, stdout, stderr io.Writer) (*data.BackendOutput, error) {
+	backendOutput := &data.BackendOutput{ExitCode: 1}
+
+	options, err := native.getOptions()
+	if err != nil {
+		return backendOutput, err
+	}
+
+	req, err := native.getRequest(ctx)
+	if err != nil {
+		return backendOutput, err
+	}
+
+	resp, err := native.getClient(options).Do(req)
+	if err != nil {
+		return backendOutput, err
+	}
+
+	defer resp.Body.Close()
+
+	var head strings.Builder
+	if options[nativeIncludeOption] {
+		if err := writeResponseHead(resp, io.MultiWriter(stdout, &head)); err != nil {
+			return backendOutput, errors.Wrap(err, "could not write response headers")
+		}
+	}
+
+	if _, err := io.Copy(stdout, resp.Body); err != nil {
+		return backendOutput, errors.Wrap(err, "could not read response body")
+	}
+
+	backendOutput.ExitCode = 0
+
+	if native.backendInput.CaptureResponse() {
+		backendOutput.Response = &data.Response{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header,
+			Head:       head.String(),
+		}
+	}
+
+	return backendOutput, nil
+}
+
+func (native *native) getAsString() string {
+	return newCurlBackend(native.backendInput, "curl").getAsString()
+}
@@ -0,0 +1,110 @@
+package call
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/pkg/errors"
+)
+
+// Longer responses are cut in error messages
+const maxTokenErrorBodyLength = 200
+
+type tokenResponse struct {
+	AccessToken  string  `json:"access_token"`
+	TokenType    string  `json:"token_type"`
+	ExpiresIn    float64 `json:"expires_in"`
+	RefreshToken string  `json:"refresh_token"`
+}
+
+func getTokenRequest(ctx context.Context, oauth2 *data.OAuth2, refreshToken string) (*http.Request, error) {
+	form := url.Values{}
+	form.Set("grant_type", oauth2.GrantType)
+
+	if oauth2.Scope != "" {
+		form.Set("scope", oauth2.Scope)
+	}
+
+	if oauth2.GrantType == data.RefreshTokenGrant {
+		form.Set("refresh_token", refreshToken)
+	}
+
+	if oauth2.ClientAuthInBody {
+		form.Set("client_id", oauth2.ClientId)
+		if oauth2.ClientSecret != "" {
+			form.Set("client_secret", oauth2.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, oauth2.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "malformed oauth2 token_url %s", oauth2.TokenUrl)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if !oauth2.ClientAuthInBody {
+		// RFC 6749 2.3.1, the id and secret are form-encoded before basic auth
+		req.SetBasicAuth(url.QueryEscape(oauth2.ClientId), url.QueryEscape(oauth2.ClientSecret))
+	}
+
+	return req, nil
+}
+
+// FetchOAuth2Token makes the call to the token endpoint. The refresh
+// token is passed separately as it can be rotated by the endpoint.
+func FetchOAuth2Token(ctx context.Context, oauth2 *data.OAuth2, refreshToken string) (data.OAuth2Token, error) {
+	token := tokenResponse{}
+	oauth2Token := data.OAuth2Token{}
+
+	req, err := getTokenRequest(ctx, oauth2, refreshToken)
+	if err != nil {
+		return oauth2Token, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return oauth2Token, errors.Wrapf(err, "could not fetch oauth2 token from %s", oauth2.TokenUrl)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return oauth2Token, errors.Wrapf(err, "could not read oauth2 token from %s", oauth2.TokenUrl)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errorBody := strings.TrimSpace(string(body))
+		if len(errorBody) > maxTokenErrorBodyLength {
+			errorBody = errorBody[:maxTokenErrorBodyLength] + "..."
+		}
+
+		return oauth2Token, errors.Errorf("could not fetch oauth2 token from %s, status %s: %s", oauth2.TokenUrl, resp.Status, errorBody)
+	}
+
+	if err := json.Unmarshal(body, &token); err != nil {
+		return oauth2Token, errors.Wrapf(err, "could not parse oauth2 token from %s", oauth2.TokenUrl)
+	}
+
+	if token.AccessToken == "" {
+		return oauth2Token, errors.Errorf("oauth2 token response from %s has no access_token", oauth2.TokenUrl)
+	}
+
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return oauth2Token, errors.Errorf("oauth2 token from %s has token_type %s, only bearer is supported", oauth2.TokenUrl, token.TokenType)
+	}
+
+	oauth2Token.AccessToken = token.AccessToken
+	oauth2Token.RefreshToken = token.RefreshToken
+	oauth2Token.ExpiresIn = time.Duration(token.ExpiresIn * float64(time.Second))
+
+	return oauth2Token, nil
+}
@@ -0,0 +1,80 @@
+package call
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_FetchOAuth2Token(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		clientId, clientSecret, _ := r.BasicAuth()
+		if r.Method != http.MethodPost || clientId != "client%2Bid" || clientSecret != "secret" ||
+			r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != "read write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	oauth2 := &data.OAuth2{
+		GrantType:    data.ClientCredentialsGrant,
+		TokenUrl:     server.URL,
+		ClientId:     "client+id",
+		ClientSecret: "secret",
+		Scope:        "read write",
+	}
+
+	token, err := FetchOAuth2Token(context.Background(), oauth2, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if token.AccessToken != "token" || token.ExpiresIn != time.Hour {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+
+	oauth2.ClientSecret = "wrong"
+	_, err = FetchOAuth2Token(context.Background(), oauth2, "")
+	if err == nil || !strings.Contains(err.Error(), `status 401 Unauthorized: {"error":"invalid_client"}`) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_FetchOAuth2TokenClientAuthInBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		if _, _, hasBasicAuth := r.BasicAuth(); hasBasicAuth ||
+			r.PostForm.Get("client_id") != "id" || r.PostForm.Get("refresh_token") != "refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Write([]byte(`{"access_token":"token","token_type":"mac"}`))
+	}))
+	defer server.Close()
+
+	oauth2 := &data.OAuth2{
+		GrantType:        data.RefreshTokenGrant,
+		TokenUrl:         server.URL,
+		ClientId:         "id",
+		ClientAuthInBody: true,
+	}
+
+	_, err := FetchOAuth2Token(context.Background(), oauth2, "refresh")
+	if err == nil || !strings.Contains(err.Error(), "has token_type mac, only bearer is supported") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
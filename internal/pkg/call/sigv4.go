@@ -0,0 +1,275 @@
+package call
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/pkg/errors"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4DateFormat = "20060102T150405Z"
+	sigV4DayFormat  = "20060102"
+)
+
+type sigV4Header struct {
+	name  string
+	value string
+}
+
+// The request as it will be sent by the backend
+type sigV4Request struct {
+	method      string
+	url         *url.URL
+	headers     []sigV4Header
+	payloadHash string
+}
+
+func hmacSha256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+
+	return mac.Sum(nil)
+}
+
+func sha256Hex(value []byte) string {
+	hash := sha256.Sum256(value)
+	return hex.EncodeToString(hash[:])
+}
+
+const upperHex = "0123456789ABCDEF"
+
+// Encodes everything but the unreserved characters of RFC 3986
+func awsUriEncode(value string) string {
+	var result strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		currentChar := value[i]
+
+		if 'a' <= currentChar && currentChar <= 'z' ||
+			'A' <= currentChar && currentChar <= 'Z' ||
+			'0' <= currentChar && currentChar <= '9' ||
+			currentChar == '-' || currentChar == '_' || currentChar == '.' || currentChar == '~' {
+			result.WriteByte(currentChar)
+			continue
+		}
+
+		result.WriteByte('%')
+		result.WriteByte(upperHex[currentChar>>4])
+		result.WriteByte(upperHex[currentChar&15])
+	}
+
+	return result.String()
+}
+
+// All services but s3 encode the already encoded path once more
+func getCanonicalUri(u *url.URL, service string) string {
+	escapedPath := u.EscapedPath()
+	if escapedPath == "" {
+		return "/"
+	}
+
+	segments := strings.Split(escapedPath, "/")
+	for i, segment := range segments {
+		unescapedSegment, err := url.PathUnescape(segment)
+		if err != nil {
+			unescapedSegment = segment
+		}
+
+		segments[i] = awsUriEncode(unescapedSegment)
+		if service != "s3" {
+			segments[i] = awsUriEncode(segments[i])
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func getCanonicalQueryString(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+
+	keyValues := [][]string{}
+
+	for _, keyValue := range strings.Split(u.RawQuery, "&") {
+		if keyValue == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(keyValue, "=")
+
+		if unescapedKey, err := url.QueryUnescape(key); err == nil {
+			key = unescapedKey
+		}
+
+		if unescapedValue, err := url.QueryUnescape(value); err == nil {
+			value = unescapedValue
+		}
+
+		keyValues = append(keyValues, []string{awsUriEncode(key), awsUriEncode(value)})
+	}
+
+	sort.Slice(keyValues, func(i, j int) bool {
+		if keyValues[i][0] != keyValues[j][0] {
+			return keyValues[i][0] < keyValues[j][0]
+		}
+
+		return keyValues[i][1] < keyValues[j][1]
+	})
+
+	encodedKeyValues := []string{}
+	for _, keyValue := range keyValues {
+		encodedKeyValues = append(encodedKeyValues, keyValue[0]+"="+keyValue[1])
+	}
+
+	return strings.Join(encodedKeyValues, "&")
+}
+
+// Returns the canonical headers and the signed header names
+func getCanonicalHeaders(headers []sigV4Header) (string, string) {
+	headerValues := map[string][]string{}
+	headerNames := []string{}
+
+	for _, header := range headers {
+		name := strings.ToLower(strings.TrimSpace(header.name))
+		if _, exists := headerValues[name]; !exists {
+			headerNames = append(headerNames, name)
+		}
+
+		headerValues[name] = append(headerValues[name], strings.Join(strings.Fields(header.value), " "))
+	}
+
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + strings.Join(headerValues[name], ",") + "\n")
+	}
+
+	return canonicalHeaders.String(), strings.Join(headerNames, ";")
+}
+
+func getSigV4Authorization(req sigV4Request, awsSigV4 *data.AwsSigV4, now time.Time) string {
+	canonicalHeaders, signedHeaders := getCanonicalHeaders(req.headers)
+
+	canonicalRequest := strings.Join([]string{
+		req.method,
+		getCanonicalUri(req.url, awsSigV4.Service),
+		getCanonicalQueryString(req.url),
+		canonicalHeaders,
+		signedHeaders,
+		req.payloadHash,
+	}, "\n")
+
+	day := now.Format(sigV4DayFormat)
+	credentialScope := strings.Join([]string{day, awsSigV4.Region, awsSigV4.Service, "aws4_request"}, "/")
+
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(sigV4DateFormat),
+		credentialScope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSha256([]byte("AWS4"+awsSigV4.SecretAccessKey), day)
+	signingKey = hmacSha256(signingKey, awsSigV4.Region)
+	signingKey = hmacSha256(signingKey, awsSigV4.Service)
+	signingKey = hmacSha256(signingKey, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	return sigV4Algorithm + " Credential=" + awsSigV4.AccessKeyId + "/" + credentialScope +
+		", SignedHeaders=" + signedHeaders + ", Signature=" + signature
+}
+
+// Backends leave out the default port in the Host header
+func getHostHeader(u *url.URL) string {
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		return u.Hostname()
+	}
+
+	return u.Host
+}
+
+// Adds the signature headers to the backend input. The body
+// is read from the temp-file so it must be created first.
+func signAwsSigV4(backendInput *data.BackendInput, now time.Time) error {
+	awsSigV4 := backendInput.Auth.AwsSigV4
+	now = now.UTC()
+
+	// The backend builds the body from the fields so it cannot be hashed
+	if len(backendInput.Multipart) > 0 && backendInput.TempFileName == "" {
+		return errors.Errorf("aws-sigv4 cannot sign a [Multipart] body sent by backend %s, use wget or native", backendInput.Backend)
+	}
+
+	body := []byte{}
+	if backendInput.TempFileName != "" {
+		var err error
+		if body, err = os.ReadFile(backendInput.TempFileName); err != nil {
+			return errors.Wrap(err, "could not read file with [Body] contents for aws-sigv4")
+		}
+	}
+
+	// Same as the backends, a body without a method is a POST
+	method := strings.ToUpper(backendInput.Method)
+	if method == "" && backendInput.TempFileName != "" {
+		method = "POST"
+	} else if method == "" {
+		method = "GET"
+	}
+
+	payloadHash := sha256Hex(body)
+
+	signatureHeaders := []sigV4Header{
+		{name: "X-Amz-Date", value: now.Format(sigV4DateFormat)},
+		{name: "X-Amz-Content-Sha256", value: payloadHash},
+	}
+
+	if awsSigV4.SessionToken != "" {
+		signatureHeaders = append(signatureHeaders, sigV4Header{name: "X-Amz-Security-Token", value: awsSigV4.SessionToken})
+	}
+
+	hasHostHeader := false
+	headers := append([]sigV4Header{}, signatureHeaders...)
+
+	for _, header := range backendInput.Headers {
+		name, value, found := strings.Cut(header, ":")
+		if !found {
+			return errors.Errorf("Malformed header, missing colon: %s", header)
+		}
+
+		if strings.EqualFold(strings.TrimSpace(name), "Host") {
+			hasHostHeader = true
+		}
+
+		headers = append(headers, sigV4Header{name: name, value: value})
+	}
+
+	if !hasHostHeader {
+		headers = append(headers, sigV4Header{name: "Host", value: getHostHeader(backendInput.Host)})
+	}
+
+	authorization := getSigV4Authorization(sigV4Request{
+		method:      method,
+		url:         backendInput.Host,
+		headers:     headers,
+		payloadHash: payloadHash,
+	}, awsSigV4, now)
+
+	for _, signatureHeader := range signatureHeaders {
+		backendInput.Headers = append(backendInput.Headers, signatureHeader.name+": "+signatureHeader.value)
+	}
+
+	backendInput.Headers = append(backendInput.Headers, "Authorization: "+authorization)
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package call
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+// From the AWS Signature Version 4 test suite
+var sigV4TestCredentials = &data.AwsSigV4{
+	Region:          "us-east-1",
+	Service:         "service",
+	AccessKeyId:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+var sigV4TestDate = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+const sigV4EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func Test_getSigV4AuthorizationTestSuite(t *testing.T) {
+	tests := map[string]struct {
+		method                string
+		url                   string
+		headers               []sigV4Header
+		body                  string
+		expectedAuthorization string
+	}{
+		"get-vanilla": {
+			method: "GET",
+			url:    "https://example.amazonaws.com/",
+			headers: []sigV4Header{
+				{name: "Host", value: "example.amazonaws.com"},
+				{name: "X-Amz-Date", value: "20150830T123600Z"},
+			},
+			expectedAuthorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		"post-vanilla": {
+			method: "POST",
+			url:    "https://example.amazonaws.com/",
+			headers: []sigV4Header{
+				{name: "Host", value: "example.amazonaws.com"},
+				{name: "X-Amz-Date", value: "20150830T123600Z"},
+			},
+			expectedAuthorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		"get-vanilla-query-order-key-case": {
+			method: "GET",
+			url:    "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			headers: []sigV4Header{
+				{name: "Host", value: "example.amazonaws.com"},
+				{name: "X-Amz-Date", value: "20150830T123600Z"},
+			},
+			expectedAuthorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		"post-x-www-form-urlencoded": {
+			method: "POST",
+			url:    "https://example.amazonaws.com/",
+			headers: []sigV4Header{
+				{name: "Content-Type", value: "application/x-www-form-urlencoded"},
+				{name: "Host", value: "example.amazonaws.com"},
+				{name: "X-Amz-Date", value: "20150830T123600Z"},
+			},
+			body:                  "Param1=value1",
+			expectedAuthorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+
+	for name, test := range tests {
+		u, _ := url.Parse(test.url)
+
+		authorization := getSigV4Authorization(sigV4Request{
+			method:      test.method,
+			url:         u,
+			headers:     test.headers,
+			payloadHash: sha256Hex([]byte(test.body)),
+		}, sigV4TestCredentials, sigV4TestDate)
+
+		if authorization != test.expectedAuthorization {
+			t.Errorf("Test: %s. Unexpected authorization: %s", name, authorization)
+		}
+	}
+}
+
+// The example from the AWS documentation on creating a signed request
+func Test_getSigV4AuthorizationIamExample(t *testing.T) {
+	iamCredentials := *sigV4TestCredentials
+	iamCredentials.Service = "iam"
+
+	u, _ := url.Parse("https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08")
+
+	authorization := getSigV4Authorization(sigV4Request{
+		method: "GET",
+		url:    u,
+		headers: []sigV4Header{
+			{name: "Content-Type", value: "application/x-www-form-urlencoded; charset=utf-8"},
+			{name: "Host", value: "iam.amazonaws.com"},
+			{name: "X-Amz-Date", value: "20150830T123600Z"},
+		},
+		payloadHash: sigV4EmptyPayloadHash,
+	}, &iamCredentials, sigV4TestDate)
+
+	expectedAuthorization := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if authorization != expectedAuthorization {
+		t.Errorf("Unexpected authorization: %s", authorization)
+	}
+}
+
+func Test_signAwsSigV4(t *testing.T) {
+	awsSigV4 := *sigV4TestCredentials
+	awsSigV4.SessionToken = "session"
+
+	hostUrl, _ := url.Parse("https://example.amazonaws.com:443/some path/?b=2&a=1")
+	backendInput := &data.BackendInput{
+		Host:    hostUrl,
+		Headers: []string{"Content-Type: application/json"},
+		Body:    []string{`{"some": "json"}`},
+		Auth:    &data.Auth{Scheme: data.AwsSigV4AuthScheme, AwsSigV4: &awsSigV4},
+	}
+
+	if err := backendInput.CreateBodyTempFile(); err != nil {
+		t.Fatalf("Could not create body temp-file: %v", err)
+	}
+	defer os.Remove(backendInput.TempFileName)
+
+	if err := signAwsSigV4(backendInput, sigV4TestDate); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedHeaders := []string{
+		"Content-Type: application/json",
+		"X-Amz-Date: 20150830T123600Z",
+		"X-Amz-Content-Sha256: " + sha256Hex([]byte(`{"some": "json"}`)),
+		"X-Amz-Security-Token: session",
+	}
+
+	if len(backendInput.Headers) != len(expectedHeaders)+1 {
+		t.Fatalf("Unexpected headers: %v", backendInput.Headers)
+	}
+
+	for i, expectedHeader := range expectedHeaders {
+		if backendInput.Headers[i] != expectedHeader {
+			t.Errorf("Expected %s, got: %s", expectedHeader, backendInput.Headers[i])
+		}
+	}
+
+	// Default port left out of the host, body without a method is a POST
+	expectedAuthorization := getSigV4Authorization(sigV4Request{
+		method: "POST",
+		url:    hostUrl,
+		headers: []sigV4Header{
+			{name: "Host", value: "example.amazonaws.com"},
+			{name: "Content-Type", value: "application/json"},
+			{name: "X-Amz-Date", value: "20150830T123600Z"},
+			{name: "X-Amz-Content-Sha256", value: sha256Hex([]byte(`{"some": "json"}`))},
+			{name: "X-Amz-Security-Token", value: "session"},
+		},
+		payloadHash: sha256Hex([]byte(`{"some": "json"}`)),
+	}, &awsSigV4, sigV4TestDate)
+
+	if authorization := backendInput.Headers[len(expectedHeaders)]; authorization != "Authorization: "+expectedAuthorization {
+		t.Errorf("Unexpected authorization: %s", authorization)
+	}
+
+	if !strings.Contains(expectedAuthorization, "SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("Unexpected signed headers: %s", expectedAuthorization)
+	}
+}
@@ -2,6 +2,7 @@ package call
 
 import (
 	"context"
+	"io"
 	"os/exec"
 	"regexp"
 	"strings"
@@ -68,6 +69,39 @@ func (wget *wget) getMethodArgument(escape bool) string {
 	return ""
 }
 
+// Escaped arguments are printed, so any secret is left out
+func (wget *wget) getAuthArguments(escape bool) []string {
+	auth := wget.backendInput.Auth
+	if escape {
+		auth = getPrintedAuth(auth)
+	}
+
+	if auth == nil {
+		return []string{}
+	}
+
+	maybeEscape := func(arg string) string {
+		if escape {
+			return utils.EscapeForShell(arg)
+		}
+
+		return arg
+	}
+
+	switch auth.Scheme {
+	case data.BasicAuthScheme:
+		// wget waits for a challenge before sending basic credentials
+		return []string{"--auth-no-challenge", "--user=" + maybeEscape(auth.User), "--password=" + maybeEscape(auth.Password)}
+	case data.DigestAuthScheme:
+		return []string{"--user=" + maybeEscape(auth.User), "--password=" + maybeEscape(auth.Password)}
+	case data.BearerAuthScheme:
+		// wget has no flag for bearer tokens
+		return []string{"--header=" + maybeEscape("Authorization: Bearer "+auth.Token)}
+	}
+
+	return []string{}
+}
+
 func (wget *wget) getBodyArgument() []string {
 	if wget.backendInput.TempFileName != "" {
 		return []string{"--body-file=" + wget.backendInput.TempFileName}
@@ -86,6 +120,8 @@ func (wget *wget) getAsCmd(ctx context.Context) *exec.Cmd {
 		args = append(args, wget.getMethodArgument(false))
 	}
 
+	args = append(args, wget.getAuthArguments(false)...)
+
 	args = append(args, wget.getHeaderArguments(false)...)
 	args = append(args, wget.getBodyArgument()...)
 
@@ -95,6 +131,10 @@ func (wget *wget) getAsCmd(ctx context.Context) *exec.Cmd {
 	return wgetCmd
 }
 
+func (wget *wget) runAsCmd(ctx context.Context, stdout, stderr io.Writer) (*data.BackendOutput, error) {
+	return runCmd(wget.getAsCmd(ctx), stdout, stderr)
+}
+
 func (wget *wget) getAsString() string {
 	args := [][]string{}
 
@@ -110,6 +150,8 @@ func (wget *wget) getAsString() string {
 		args = append(args, []string{wget.getMethodArgument(true)})
 	}
 
+	args = append(args, wget.getAuthArguments(true))
+
 	for _, header := range wget.getHeaderArguments(true) {
 		args = append(args, []string{header})
 	}
@@ -0,0 +1,201 @@
+package convert
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/jonaslu/ain/internal/pkg/disk"
+)
+
+// Folders and requests of an imported Postman or Insomnia
+// collection before they are written as files
+type collectionFolder struct {
+	name         string
+	vars         [][]string
+	baseTemplate template
+	folders      []*collectionFolder
+	requests     []collectionRequest
+	envFiles     []collectionEnvFile
+	notImported  importNotes
+}
+
+type collectionRequest struct {
+	name        string
+	template    template
+	notImported importNotes
+}
+
+// Selected with -e, e g .env.production
+type collectionEnvFile struct {
+	name string
+	vars [][]string
+}
+
+// Features in the collection that have no counterpart in ain
+type importNotes []string
+
+func (n *importNotes) add(note string) {
+	for _, existingNote := range *n {
+		if existingNote == note {
+			return
+		}
+	}
+
+	*n = append(*n, note)
+}
+
+var nonVarNameCharsRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Same as the shell and .env-files accept
+func getVarName(name string) string {
+	varName := nonVarNameCharsRe.ReplaceAllString(strings.TrimSpace(name), "_")
+	if varName == "" || varName[0] >= '0' && varName[0] <= '9' {
+		varName = "_" + varName
+	}
+
+	return varName
+}
+
+// Escapes the text and rewrites the placeholders as ${VAR}. A placeholder
+// getVarName can't make a variable of is noted and kept as text.
+func translateVars(text string, placeholderRe *regexp.Regexp, getPlaceholderVarName func(placeholder string) (string, string), notes *importNotes) string {
+	return placeholderRe.ReplaceAllStringFunc(escape(text), func(placeholder string) string {
+		varName, notImported := getPlaceholderVarName(placeholder)
+		if notImported != "" {
+			notes.add(notImported)
+			return placeholder
+		}
+
+		return "${" + getVarName(varName) + "}"
+	})
+}
+
+// The [Headers] or [Query] line removing an inherited [Auth]
+func getAuthRemovals(auth string) ([]string, []string) {
+	// apikey header|query <name> <value>
+	if fields := strings.Fields(auth); len(fields) > 2 && fields[0] == "apikey" {
+		if fields[1] == "query" {
+			return nil, []string{"-" + fields[2]}
+		}
+
+		return []string{"-" + fields[2]}, nil
+	}
+
+	return []string{"-Authorization"}, nil
+}
+
+// Later values replace earlier ones with the same name
+func mergeVars(vars ...[][]string) [][]string {
+	mergedVars := [][]string{}
+	varIndexes := map[string]int{}
+
+	for _, keyValues := range vars {
+		for _, keyValue := range keyValues {
+			if i, exists := varIndexes[keyValue[0]]; exists {
+				mergedVars[i] = keyValue
+				continue
+			}
+
+			varIndexes[keyValue[0]] = len(mergedVars)
+			mergedVars = append(mergedVars, keyValue)
+		}
+	}
+
+	return mergedVars
+}
+
+func formatEnvFile(vars [][]string) string {
+	var envFile strings.Builder
+	for _, keyValue := range vars {
+		envFile.WriteString(keyValue[0] + "=" + disk.QuoteEnvValue(keyValue[1]) + "\n")
+	}
+
+	return envFile.String()
+}
+
+func getUniqueName(name, fallbackName string, takenNames map[string]bool) string {
+	if name == "" {
+		name = fallbackName
+	}
+
+	uniqueName := name
+	for i := 2; takenNames[uniqueName]; i++ {
+		uniqueName = fmt.Sprintf("%s-%d", name, i)
+	}
+
+	takenNames[uniqueName] = true
+	return uniqueName
+}
+
+// The folder tree is written as is. Auth goes into a _base.ain so it's
+// inherited the same way, and variables into an .env-file holding the
+// variables of the folders above too as ain only reads one .env-file.
+func (f *collectionFolder) addImportedFiles(folderPath string, parentVars [][]string, importedFiles *[]data.ImportedFile, notImported *[]data.NotImported) {
+	isTopFolder := folderPath == ""
+
+	baseTemplate := f.baseTemplate
+	if isTopFolder {
+		baseTemplate.backend = "curl"
+	}
+
+	if baseTemplate.String() != "" {
+		*importedFiles = append(*importedFiles, data.ImportedFile{
+			Filename: path.Join(folderPath, baseTemplateFilename),
+			Contents: baseTemplate.String(),
+		})
+	}
+
+	folderVars := mergeVars(parentVars, f.vars)
+	if len(f.vars) > 0 {
+		*importedFiles = append(*importedFiles, data.ImportedFile{
+			Filename: path.Join(folderPath, ".env"),
+			Contents: formatEnvFile(folderVars),
+		})
+	}
+
+	takenEnvFileNames := map[string]bool{}
+
+	for _, envFile := range f.envFiles {
+		*importedFiles = append(*importedFiles, data.ImportedFile{
+			Filename: path.Join(folderPath, ".env."+getUniqueName(getFilenameSlug(envFile.name), "environment", takenEnvFileNames)),
+			Contents: formatEnvFile(mergeVars(folderVars, envFile.vars)),
+		})
+	}
+
+	for _, what := range f.notImported {
+		*notImported = append(*notImported, data.NotImported{Filename: folderPath, What: what})
+	}
+
+	takenNames := map[string]bool{}
+
+	for _, request := range f.requests {
+		filename := path.Join(folderPath, getUniqueName(getFilenameSlug(request.name), "request", takenNames)+".ain")
+
+		*importedFiles = append(*importedFiles, data.ImportedFile{
+			Filename: filename,
+			Contents: request.template.String(),
+		})
+
+		for _, what := range request.notImported {
+			*notImported = append(*notImported, data.NotImported{Filename: filename, What: what})
+		}
+	}
+
+	for _, folder := range f.folders {
+		subFolderPath := path.Join(folderPath, getUniqueName(getFilenameSlug(folder.name), "folder", takenNames))
+		folder.addImportedFiles(subFolderPath, folderVars, importedFiles, notImported)
+	}
+}
+
+// Returns the files to write and what could not be imported
+func (f *collectionFolder) getImportedFiles() ([]data.ImportedFile, []data.NotImported) {
+	importedFiles := []data.ImportedFile{}
+	notImported := []data.NotImported{}
+
+	f.addImportedFiles("", nil, &importedFiles, &notImported)
+
+	return importedFiles, notImported
+}
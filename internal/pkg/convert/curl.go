@@ -0,0 +1,355 @@
+package convert
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Flags taking no value that are passed on to [BackendOptions]
+var curlBooleanFlags = []string{
+	"-s", "--silent", "-S", "--show-error", "-v", "--verbose", "-k", "--insecure",
+	"-L", "--location", "-i", "--include", "-f", "--fail", "--compressed",
+	"-g", "--globoff", "-N", "--no-buffer", "--http1.0", "--http1.1", "--http2", "--http3",
+	"-4", "--ipv4", "-6", "--ipv6", "-j", "--junk-session-cookies", "-O", "--remote-name",
+}
+
+// Short flags where the value can be written right after the flag, e g -XPOST
+const curlShortFlagsWithValue = "XHdFuAeb"
+
+// Characters that need no quoting in [BackendOptions]
+var unquotedBackendOptionRe = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+type curlCommand struct {
+	urls           []string
+	method         string
+	headers        []string
+	data           []string
+	dataFile       string
+	multipart      []string
+	user           string
+	bearerToken    string
+	get            bool
+	head           bool
+	digest         bool
+	backendOptions []string
+}
+
+func quoteBackendOption(value string) string {
+	if unquotedBackendOptionRe.MatchString(value) {
+		return value
+	}
+
+	return utils.EscapeForShell(value)
+}
+
+func isCurlBooleanFlag(flag string) bool {
+	for _, curlBooleanFlag := range curlBooleanFlags {
+		if flag == curlBooleanFlag {
+			return true
+		}
+	}
+
+	// Several short flags in one, e g -sSL
+	if len(flag) > 2 && !strings.HasPrefix(flag, "--") {
+		for _, shortFlag := range flag[1:] {
+			if !isCurlBooleanFlag("-" + string(shortFlag)) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// Same as curl, spaces are %20 and not +
+func curlUrlEncode(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+func getCurlUrlEncodedData(value string) (string, error) {
+	if strings.Contains(value, "@") && !strings.Contains(value, "=") {
+		return "", errors.Errorf("cannot import --data-urlencode from a file: %s", value)
+	}
+
+	name, content, found := strings.Cut(value, "=")
+	if !found {
+		return curlUrlEncode(value), nil
+	}
+
+	if name == "" {
+		return curlUrlEncode(content), nil
+	}
+
+	return name + "=" + curlUrlEncode(content), nil
+}
+
+func (c *curlCommand) addData(flag, value string) error {
+	switch {
+	case flag == "--data-urlencode":
+		encodedValue, err := getCurlUrlEncodedData(value)
+		if err != nil {
+			return err
+		}
+
+		c.data = append(c.data, encodedValue)
+
+	// --data-raw takes the @ as is
+	case strings.HasPrefix(value, "@") && flag != "--data-raw":
+		if value == "@-" {
+			return errors.New("cannot import a body read from stdin")
+		}
+
+		c.dataFile = strings.TrimPrefix(value, "@")
+
+	default:
+		c.data = append(c.data, value)
+	}
+
+	if c.dataFile != "" && len(c.data) > 0 {
+		return errors.New("cannot import a body both from a file and from text")
+	}
+
+	return nil
+}
+
+func (c *curlCommand) addFlag(flag, value string) error {
+	switch flag {
+	case "-X", "--request":
+		c.method = value
+
+	case "-H", "--header":
+		c.headers = append(c.headers, value)
+
+	case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw", "--data-urlencode":
+		return c.addData(flag, value)
+
+	case "--json":
+		c.headers = append(c.headers, "Content-Type: application/json", "Accept: application/json")
+		return c.addData(flag, value)
+
+	case "-F", "--form":
+		c.multipart = append(c.multipart, value)
+
+	case "--form-string":
+		if name, formValue, _ := strings.Cut(value, "="); strings.HasPrefix(formValue, "@") {
+			return errors.Errorf("cannot import --form-string %s, the value would be read as a file", name)
+		}
+
+		c.multipart = append(c.multipart, value)
+
+	case "-u", "--user":
+		if !strings.Contains(value, ":") {
+			// curl asks for the password
+			c.backendOptions = append(c.backendOptions, flag+" "+quoteBackendOption(value))
+			return nil
+		}
+
+		c.user = value
+
+	case "--oauth2-bearer":
+		c.bearerToken = value
+
+	case "-A", "--user-agent":
+		c.headers = append(c.headers, "User-Agent: "+value)
+
+	case "-e", "--referer":
+		c.headers = append(c.headers, "Referer: "+value)
+
+	case "-b", "--cookie":
+		// Without a = it's a file to read cookies from
+		if !strings.Contains(value, "=") {
+			c.backendOptions = append(c.backendOptions, flag+" "+quoteBackendOption(value))
+			return nil
+		}
+
+		c.headers = append(c.headers, "Cookie: "+value)
+
+	case "--url":
+		c.urls = append(c.urls, value)
+
+	default:
+		c.backendOptions = append(c.backendOptions, flag+" "+quoteBackendOption(value))
+	}
+
+	return nil
+}
+
+func isCurlFlagWithValue(flag string) bool {
+	switch flag {
+	case "-X", "--request", "-H", "--header", "-d", "--data", "--data-ascii", "--data-binary",
+		"--data-raw", "--data-urlencode", "--json", "-F", "--form", "--form-string", "-u", "--user",
+		"--oauth2-bearer", "-A", "--user-agent", "-e", "--referer", "-b", "--cookie", "--url":
+		return true
+	}
+
+	return false
+}
+
+func parseCurlArgs(args []string) (*curlCommand, error) {
+	c := &curlCommand{}
+
+	if len(args) > 0 && (args[0] == "curl" || args[0] == "curl.exe") {
+		args = args[1:]
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "-G" || arg == "--get":
+			c.get = true
+
+		case arg == "-I" || arg == "--head":
+			c.head = true
+
+		case arg == "--digest":
+			c.digest = true
+
+		case arg == "--basic":
+			// The default with -u
+
+		case !strings.HasPrefix(arg, "-"):
+			c.urls = append(c.urls, arg)
+
+		case isCurlBooleanFlag(arg):
+			c.backendOptions = append(c.backendOptions, arg)
+
+		case len(arg) > 2 && !strings.HasPrefix(arg, "--") && strings.ContainsRune(curlShortFlagsWithValue, rune(arg[1])):
+			if err := c.addFlag(arg[:2], arg[2:]); err != nil {
+				return nil, err
+			}
+
+		case isCurlFlagWithValue(arg):
+			if i+1 == len(args) {
+				return nil, errors.Errorf("missing value after %s", arg)
+			}
+
+			i++
+			if err := c.addFlag(arg, args[i]); err != nil {
+				return nil, err
+			}
+
+		// Unknown flags are guessed to take a value unless
+		// followed by another flag or something like a url
+		case i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") && !strings.Contains(args[i+1], "://"):
+			i++
+			c.backendOptions = append(c.backendOptions, arg+" "+quoteBackendOption(args[i]))
+
+		default:
+			c.backendOptions = append(c.backendOptions, arg)
+		}
+	}
+
+	if len(c.urls) == 0 {
+		return nil, errors.New("missing url in the curl command")
+	}
+
+	if len(c.urls) > 1 {
+		return nil, errors.Errorf("cannot import several urls: %s", strings.Join(c.urls, " "))
+	}
+
+	return c, nil
+}
+
+func hasHeader(headers []string, wantedHeaderName string) bool {
+	for _, header := range headers {
+		if headerName, _, _ := strings.Cut(header, ":"); strings.EqualFold(strings.TrimSpace(headerName), wantedHeaderName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Splits off the query string (and any #fragment, it's never sent)
+func splitUrl(rawUrl string) (string, []string) {
+	rawUrl, _, _ = strings.Cut(rawUrl, "#")
+
+	host, rawQuery, _ := strings.Cut(rawUrl, "?")
+	if rawQuery == "" {
+		return host, nil
+	}
+
+	return host, strings.Split(rawQuery, "&")
+}
+
+func (c *curlCommand) toTemplate() template {
+	host, query := splitUrl(c.urls[0])
+
+	t := template{
+		host:           escape(host),
+		query:          escapeLines(query),
+		headers:        escapeLines(c.headers),
+		method:         c.method,
+		backend:        "curl",
+		backendOptions: escapeLines(c.backendOptions),
+	}
+
+	switch {
+	case c.user != "" && c.digest:
+		t.auth = escape("digest " + c.user)
+	case c.user != "":
+		t.auth = escape("basic " + c.user)
+	case c.bearerToken != "":
+		t.auth = escape("bearer " + c.bearerToken)
+	}
+
+	hasData := len(c.data) > 0 || c.dataFile != ""
+
+	if c.get {
+		// -G sends the data as the query string
+		for _, data := range c.data {
+			t.query = append(t.query, escapeLines(strings.Split(data, "&"))...)
+		}
+
+		hasData = false
+	} else if len(c.data) > 0 {
+		t.body = escapeLines(strings.Split(strings.Join(c.data, "&"), "\n"))
+	} else {
+		t.bodyFile = escape(c.dataFile)
+	}
+
+	t.multipart = escapeLines(c.multipart)
+
+	// Set by curl, but not by all backends
+	if hasData && !hasHeader(c.headers, "Content-Type") {
+		t.headers = append(t.headers, "Content-Type: application/x-www-form-urlencoded")
+	}
+
+	if t.method == "" {
+		switch {
+		case c.head:
+			t.method = "HEAD"
+		case hasData || len(c.multipart) > 0:
+			t.method = "POST"
+		}
+	}
+
+	return t
+}
+
+// ImportCurl turns a curl command into a template. A single argument
+// is split like a shell would, e g a "Copy as cURL" from a browser.
+func ImportCurl(args []string) (string, error) {
+	if len(args) == 1 {
+		commandLine := strings.NewReplacer("\\\r\n", " ", "\\\n", " ").Replace(args[0])
+
+		var err error
+		if args, err = utils.TokenizeLine(commandLine); err != nil {
+			return "", errors.Wrap(err, "cannot split the curl command")
+		}
+	}
+
+	c, err := parseCurlArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	return c.toTemplate().String(), nil
+}
@@ -0,0 +1,171 @@
+package convert
+
+import (
+	"testing"
+)
+
+func Test_ImportCurl(t *testing.T) {
+	tests := map[string]struct {
+		args             []string
+		expectedTemplate string
+	}{
+		"Copy as cURL": {
+			args: []string{"curl 'https://api.example.com/users?page=1&q=a%20b#top' \\\n  -H 'accept: application/json' \\\n  -H 'x-color: #fff' \\\n  --data-raw '{\"name\":\"${USER}\"}' \\\n  --compressed"},
+			expectedTemplate: `[Host]
+https://api.example.com/users
+
+[Query]
+page=1
+q=a%20b
+
+[Headers]
+accept: application/json
+x-color: ` + "`" + `#fff
+Content-Type: application/x-www-form-urlencoded
+
+[Method]
+POST
+
+[Body]
+{"name":"` + "`" + `${USER}"}
+
+[Backend]
+curl
+
+[BackendOptions]
+--compressed
+`,
+		},
+		"Split by the shell": {
+			args: []string{"curl", "-XPUT", "-u", "admin:secret", "--digest", "-sS", "-m", "10", "-o", "out file.json", "https://example.com", "-F", "avatar=@avatar.png;type=image/png"},
+			expectedTemplate: `[Host]
+https://example.com
+
+[Method]
+PUT
+
+[Auth]
+digest admin:secret
+
+[Multipart]
+avatar=@avatar.png;type=image/png
+
+[Backend]
+curl
+
+[BackendOptions]
+-sS
+-m 10
+-o 'out file.json'
+`,
+		},
+		"Get with data": {
+			args: []string{"curl -G https://example.com/search -d q=ain --data-urlencode 'tag=a b' -I"},
+			expectedTemplate: `[Host]
+https://example.com/search
+
+[Query]
+q=ain
+tag=a%20b
+
+[Method]
+HEAD
+
+[Backend]
+curl
+`,
+		},
+		"Body file and json": {
+			args: []string{"curl --url https://example.com --json @user.json --oauth2-bearer token -b 'session=1'"},
+			expectedTemplate: `[Host]
+https://example.com
+
+[Headers]
+Content-Type: application/json
+Accept: application/json
+Cookie: session=1
+
+[Method]
+POST
+
+[Auth]
+bearer token
+
+[BodyFile]
+user.json
+
+[Backend]
+curl
+`,
+		},
+		"Data from file": {
+			args: []string{"curl https://example.com -H 'Content-Type: text/plain' --data-binary @body.txt"},
+			expectedTemplate: `[Host]
+https://example.com
+
+[Headers]
+Content-Type: text/plain
+
+[Method]
+POST
+
+[BodyFile]
+body.txt
+
+[Backend]
+curl
+`,
+		},
+	}
+
+	for name, test := range tests {
+		template, err := ImportCurl(test.args)
+		if err != nil {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+			continue
+		}
+
+		if template != test.expectedTemplate {
+			t.Errorf("Test: %s. Unexpected template:\n%s", name, template)
+		}
+	}
+}
+
+func Test_ImportCurlBadCases(t *testing.T) {
+	tests := map[string]struct {
+		args          []string
+		expectedError string
+	}{
+		"No url": {
+			args:          []string{"curl -X POST"},
+			expectedError: "missing url in the curl command",
+		},
+		"Several urls": {
+			args:          []string{"curl https://a https://b"},
+			expectedError: "cannot import several urls: https://a https://b",
+		},
+		"Body from stdin": {
+			args:          []string{"curl https://a -d @-"},
+			expectedError: "cannot import a body read from stdin",
+		},
+		"Body from file and text": {
+			args:          []string{"curl https://a -d @body.json -d a=1"},
+			expectedError: "cannot import a body both from a file and from text",
+		},
+		"Missing value": {
+			args:          []string{"curl https://a -H"},
+			expectedError: "missing value after -H",
+		},
+		"Unterminated quote": {
+			args:          []string{"curl 'https://a"},
+			expectedError: "cannot split the curl command: Unterminated quote sequence: curl 'htt...",
+		},
+	}
+
+	for name, test := range tests {
+		_, err := ImportCurl(test.args)
+		if err == nil || err.Error() != test.expectedError {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,533 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/pkg/errors"
+)
+
+// Same as curl, sent if a body has no Content-Type
+const defaultExportMimeType = "application/x-www-form-urlencoded"
+
+const postmanCollectionSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// The parts of an assembled request both formats are made from
+type exportedHttpRequest struct {
+	method  string
+	url     *url.URL
+	headers [][]string
+	// Not set for multipart
+	mimeType  string
+	body      string
+	hasBody   bool
+	multipart []data.MultipartField
+}
+
+type harExportNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harExportParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type harExportPostData struct {
+	MimeType string           `json:"mimeType"`
+	Text     string           `json:"text"`
+	Params   []harExportParam `json:"params,omitempty"`
+}
+
+type harExportRequest struct {
+	Method      string               `json:"method"`
+	Url         string               `json:"url"`
+	HttpVersion string               `json:"httpVersion"`
+	Cookies     []harExportNameValue `json:"cookies"`
+	Headers     []harExportNameValue `json:"headers"`
+	QueryString []harExportNameValue `json:"queryString"`
+	PostData    *harExportPostData   `json:"postData,omitempty"`
+	HeadersSize int                  `json:"headersSize"`
+	BodySize    int                  `json:"bodySize"`
+}
+
+// The request was never sent, so the response is empty
+type harExportResponse struct {
+	Status      int                  `json:"status"`
+	StatusText  string               `json:"statusText"`
+	HttpVersion string               `json:"httpVersion"`
+	Cookies     []harExportNameValue `json:"cookies"`
+	Headers     []harExportNameValue `json:"headers"`
+	Content     struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+	} `json:"content"`
+	RedirectURL string `json:"redirectURL"`
+	HeadersSize int    `json:"headersSize"`
+	BodySize    int    `json:"bodySize"`
+}
+
+type harExportEntry struct {
+	StartedDateTime string            `json:"startedDateTime"`
+	Time            int               `json:"time"`
+	Request         harExportRequest  `json:"request"`
+	Response        harExportResponse `json:"response"`
+	Cache           struct{}          `json:"cache"`
+	Timings         struct {
+		Send    int `json:"send"`
+		Wait    int `json:"wait"`
+		Receive int `json:"receive"`
+	} `json:"timings"`
+	Comment string `json:"comment"`
+}
+
+type harExport struct {
+	Log struct {
+		Version string `json:"version"`
+		Creator struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"creator"`
+		Entries []harExportEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type postmanExportKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+type postmanExportFormField struct {
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	Type        string `json:"type"`
+	Src         string `json:"src,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type postmanExportBody struct {
+	Mode     string                   `json:"mode"`
+	Raw      string                   `json:"raw,omitempty"`
+	Formdata []postmanExportFormField `json:"formdata,omitempty"`
+	Options  map[string]interface{}   `json:"options,omitempty"`
+}
+
+type postmanExportRequest struct {
+	Method string                  `json:"method"`
+	Header []postmanExportKeyValue `json:"header"`
+	Url    string                  `json:"url"`
+	Body   *postmanExportBody      `json:"body,omitempty"`
+	// The parameters are in a list named after the type, e g "basic": [...]
+	Auth map[string]interface{} `json:"auth,omitempty"`
+}
+
+// A folder has items, a request has a request
+type postmanExportItem struct {
+	Name    string                `json:"name"`
+	Item    []*postmanExportItem  `json:"item,omitempty"`
+	Request *postmanExportRequest `json:"request,omitempty"`
+}
+
+type postmanExportCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item []*postmanExportItem `json:"item"`
+}
+
+// Keeps the & in urls readable, json.Marshal writes it as \u0026
+func marshalExport(export interface{}) ([]byte, error) {
+	var exportBytes bytes.Buffer
+
+	encoder := json.NewEncoder(&exportBytes)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(export); err != nil {
+		return nil, err
+	}
+
+	return exportBytes.Bytes(), nil
+}
+
+func getExportedHeader(headers [][]string, wantedHeaderName string) (string, bool) {
+	for _, header := range headers {
+		if strings.EqualFold(header[0], wantedHeaderName) {
+			return header[1], true
+		}
+	}
+
+	return "", false
+}
+
+// Method, headers and body as the backends send them
+func getExportedHttpRequest(exportedRequest data.ExportedRequest, notExported *[]data.NotExported) (exportedHttpRequest, error) {
+	backendInput := exportedRequest.BackendInput
+	httpRequest := exportedHttpRequest{
+		method:    strings.ToUpper(backendInput.Method),
+		url:       backendInput.Host,
+		headers:   [][]string{},
+		multipart: backendInput.Multipart,
+	}
+
+	for _, header := range backendInput.Headers {
+		headerName, headerValue, found := strings.Cut(header, ":")
+		if !found {
+			return httpRequest, errors.Errorf("malformed header in %s, missing colon: %s", exportedRequest.Name, header)
+		}
+
+		httpRequest.headers = append(httpRequest.headers, []string{strings.TrimSpace(headerName), strings.TrimSpace(headerValue)})
+	}
+
+	hasBody := len(backendInput.Body) > 0 || backendInput.BodyFileName != "" || len(backendInput.Multipart) > 0
+
+	if httpRequest.method == "" {
+		// Same as curl, a body without a method is a POST
+		httpRequest.method = http.MethodGet
+		if hasBody {
+			httpRequest.method = http.MethodPost
+		}
+	}
+
+	if backendInput.BodyFileName != "" {
+		bodyBytes, err := os.ReadFile(backendInput.BodyFileName)
+		if err != nil {
+			return httpRequest, errors.Wrapf(err, "could not read [BodyFile] %s", backendInput.BodyFileName)
+		}
+
+		// Neither format can hold binary request bodies
+		if utf8.Valid(bodyBytes) {
+			httpRequest.body = string(bodyBytes)
+			httpRequest.hasBody = true
+		} else {
+			*notExported = append(*notExported, data.NotExported{Name: exportedRequest.Name, What: "the binary [BodyFile] " + backendInput.BodyFileName})
+		}
+	} else if len(backendInput.Body) > 0 {
+		httpRequest.body = strings.Join(backendInput.Body, "\n")
+		httpRequest.hasBody = true
+	}
+
+	if hasBody && len(backendInput.Multipart) == 0 {
+		mimeType, found := getExportedHeader(httpRequest.headers, "Content-Type")
+		if !found {
+			mimeType = defaultExportMimeType
+			httpRequest.headers = append(httpRequest.headers, []string{"Content-Type", mimeType})
+		}
+
+		httpRequest.mimeType = mimeType
+	}
+
+	return httpRequest, nil
+}
+
+func unescapeQueryPart(queryPart string) string {
+	if unescapedQueryPart, err := url.QueryUnescape(queryPart); err == nil {
+		return unescapedQueryPart
+	}
+
+	return queryPart
+}
+
+// In the order given, url.Values would sort them
+func getHarQueryString(requestUrl *url.URL) []harExportNameValue {
+	queryString := []harExportNameValue{}
+	if requestUrl.RawQuery == "" {
+		return queryString
+	}
+
+	for _, param := range strings.Split(requestUrl.RawQuery, "&") {
+		name, value, _ := strings.Cut(param, "=")
+		queryString = append(queryString, harExportNameValue{Name: unescapeQueryPart(name), Value: unescapeQueryPart(value)})
+	}
+
+	return queryString
+}
+
+// HAR has no auth of its own, basic and bearer are sent as the
+// Authorization header. The others are computed per call.
+func getHarAuthHeader(exportedRequest data.ExportedRequest, notExported *[]data.NotExported) []string {
+	auth := exportedRequest.BackendInput.Auth
+	if auth == nil {
+		return nil
+	}
+
+	switch auth.Scheme {
+	case data.BasicAuthScheme:
+		return []string{"Authorization", "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.User+":"+auth.Password))}
+	case data.BearerAuthScheme:
+		return []string{"Authorization", "Bearer " + auth.Token}
+	}
+
+	*notExported = append(*notExported, data.NotExported{Name: exportedRequest.Name, What: auth.Scheme + " auth"})
+	return nil
+}
+
+func getHarExportEntry(exportedRequest data.ExportedRequest, exportedAt time.Time, notExported *[]data.NotExported) (harExportEntry, error) {
+	entry := harExportEntry{
+		StartedDateTime: exportedAt.Format(time.RFC3339),
+		Comment:         exportedRequest.Name,
+	}
+
+	httpRequest, err := getExportedHttpRequest(exportedRequest, notExported)
+	if err != nil {
+		return entry, err
+	}
+
+	if authHeader := getHarAuthHeader(exportedRequest, notExported); authHeader != nil {
+		if _, found := getExportedHeader(httpRequest.headers, "Authorization"); !found {
+			httpRequest.headers = append(httpRequest.headers, authHeader)
+		}
+	}
+
+	headers := []harExportNameValue{}
+	for _, header := range httpRequest.headers {
+		headers = append(headers, harExportNameValue{Name: header[0], Value: header[1]})
+	}
+
+	entry.Request = harExportRequest{
+		Method:      httpRequest.method,
+		Url:         httpRequest.url.String(),
+		HttpVersion: "HTTP/1.1",
+		Cookies:     []harExportNameValue{},
+		Headers:     headers,
+		QueryString: getHarQueryString(httpRequest.url),
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+
+	if httpRequest.hasBody {
+		entry.Request.PostData = &harExportPostData{MimeType: httpRequest.mimeType, Text: httpRequest.body}
+		entry.Request.BodySize = len(httpRequest.body)
+	}
+
+	if len(httpRequest.multipart) > 0 {
+		params := []harExportParam{}
+		for _, field := range httpRequest.multipart {
+			params = append(params, harExportParam{Name: field.Name, Value: field.Value, FileName: field.Filename, ContentType: field.ContentType})
+		}
+
+		// The boundary is made up when sending
+		entry.Request.PostData = &harExportPostData{MimeType: "multipart/form-data", Params: params}
+		entry.Request.BodySize = -1
+	}
+
+	entry.Response = harExportResponse{
+		Cookies:     []harExportNameValue{},
+		Headers:     []harExportNameValue{},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+
+	return entry, nil
+}
+
+// ExportHar returns the requests as entries in a HAR log, to open in
+// the browser devtools or any other tool importing HAR files
+func ExportHar(exportedRequests []data.ExportedRequest, ainVersion string, exportedAt time.Time) ([]byte, []data.NotExported, error) {
+	export := harExport{}
+	export.Log.Version = "1.2"
+	export.Log.Creator.Name = "ain"
+	export.Log.Creator.Version = ainVersion
+	export.Log.Entries = []harExportEntry{}
+
+	notExported := []data.NotExported{}
+
+	for _, exportedRequest := range exportedRequests {
+		entry, err := getHarExportEntry(exportedRequest, exportedAt, &notExported)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		export.Log.Entries = append(export.Log.Entries, entry)
+	}
+
+	harBytes, err := marshalExport(export)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not write HAR")
+	}
+
+	return harBytes, notExported, nil
+}
+
+func getPostmanAuthParams(params ...string) []postmanExportKeyValue {
+	authParams := []postmanExportKeyValue{}
+	for i := 0; i < len(params); i += 2 {
+		authParams = append(authParams, postmanExportKeyValue{Key: params[i], Value: params[i+1], Type: "string"})
+	}
+
+	return authParams
+}
+
+func getPostmanExportAuth(auth *data.Auth) map[string]interface{} {
+	if auth == nil {
+		return nil
+	}
+
+	var authType string
+	var authParams []postmanExportKeyValue
+
+	switch auth.Scheme {
+	case data.BasicAuthScheme, data.DigestAuthScheme:
+		authType = auth.Scheme
+		authParams = getPostmanAuthParams("username", auth.User, "password", auth.Password)
+	case data.BearerAuthScheme:
+		authType = auth.Scheme
+		authParams = getPostmanAuthParams("token", auth.Token)
+	case data.AwsSigV4AuthScheme:
+		authType = "awsv4"
+		authParams = getPostmanAuthParams(
+			"accessKey", auth.AwsSigV4.AccessKeyId,
+			"secretKey", auth.AwsSigV4.SecretAccessKey,
+			"region", auth.AwsSigV4.Region,
+			"service", auth.AwsSigV4.Service,
+			"sessionToken", auth.AwsSigV4.SessionToken,
+		)
+	default:
+		return nil
+	}
+
+	return map[string]interface{}{"type": authType, authType: authParams}
+}
+
+// Picks the language in the body editor, Postman shows text otherwise
+func getPostmanRawLanguage(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return ""
+	}
+
+	for language, contentType := range postmanRawContentTypes {
+		if contentType == mediaType {
+			return language
+		}
+	}
+
+	return ""
+}
+
+func getPostmanExportRequest(exportedRequest data.ExportedRequest, notExported *[]data.NotExported) (*postmanExportRequest, error) {
+	httpRequest, err := getExportedHttpRequest(exportedRequest, notExported)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &postmanExportRequest{
+		Method: httpRequest.method,
+		Header: []postmanExportKeyValue{},
+		Url:    httpRequest.url.String(),
+		Auth:   getPostmanExportAuth(exportedRequest.BackendInput.Auth),
+	}
+
+	for _, header := range httpRequest.headers {
+		request.Header = append(request.Header, postmanExportKeyValue{Key: header[0], Value: header[1]})
+	}
+
+	if httpRequest.hasBody {
+		request.Body = &postmanExportBody{Mode: "raw", Raw: httpRequest.body}
+
+		if language := getPostmanRawLanguage(httpRequest.mimeType); language != "" {
+			request.Body.Options = map[string]interface{}{"raw": map[string]string{"language": language}}
+		}
+	}
+
+	if len(httpRequest.multipart) > 0 {
+		formdata := []postmanExportFormField{}
+		for _, field := range httpRequest.multipart {
+			if field.Filename != "" {
+				formdata = append(formdata, postmanExportFormField{Key: field.Name, Type: "file", Src: field.Filename, ContentType: field.ContentType})
+				continue
+			}
+
+			formdata = append(formdata, postmanExportFormField{Key: field.Name, Value: field.Value, Type: "text"})
+		}
+
+		request.Body = &postmanExportBody{Mode: "formdata", Formdata: formdata}
+	}
+
+	return request, nil
+}
+
+func (i *postmanExportItem) getFolder(name string) *postmanExportItem {
+	for _, item := range i.Item {
+		if item.Request == nil && item.Name == name {
+			return item
+		}
+	}
+
+	folder := &postmanExportItem{Name: name}
+	i.Item = append(i.Item, folder)
+
+	return folder
+}
+
+// E g users/api.ain#get-user is the request get-user in the folders users and api
+func getPostmanItemPath(requestName string) ([]string, string) {
+	filename := requestName
+	name := ""
+	if idx := strings.LastIndex(requestName, "#"); idx != -1 {
+		filename = requestName[:idx]
+		name = requestName[idx+1:]
+	}
+
+	folders := []string{}
+	if dir := path.Dir(filename); dir != "." {
+		folders = strings.Split(dir, "/")
+	}
+
+	itemName := strings.TrimSuffix(path.Base(filename), ".ain")
+	if name != "" {
+		folders = append(folders, itemName)
+		itemName = name
+	}
+
+	return folders, itemName
+}
+
+// ExportPostman returns the requests as a Postman v2.1 collection,
+// with folders for the folders and files the requests are in
+func ExportPostman(collectionName string, exportedRequests []data.ExportedRequest) ([]byte, []data.NotExported, error) {
+	root := &postmanExportItem{Item: []*postmanExportItem{}}
+	notExported := []data.NotExported{}
+
+	for _, exportedRequest := range exportedRequests {
+		request, err := getPostmanExportRequest(exportedRequest, &notExported)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		folders, itemName := getPostmanItemPath(exportedRequest.Name)
+
+		folder := root
+		for _, folderName := range folders {
+			folder = folder.getFolder(folderName)
+		}
+
+		folder.Item = append(folder.Item, &postmanExportItem{Name: itemName, Request: request})
+	}
+
+	collection := postmanExportCollection{Item: root.Item}
+	collection.Info.Name = collectionName
+	collection.Info.Schema = postmanCollectionSchema
+
+	collectionBytes, err := marshalExport(collection)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not write Postman collection")
+	}
+
+	return collectionBytes, notExported, nil
+}
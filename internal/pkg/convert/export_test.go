@@ -0,0 +1,252 @@
+package convert
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func mustParseUrl(t *testing.T, rawUrl string) *url.URL {
+	requestUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		t.Fatalf("Could not parse url %s: %v", rawUrl, err)
+	}
+
+	return requestUrl
+}
+
+func Test_ExportHar(t *testing.T) {
+	exportedRequests := []data.ExportedRequest{
+		{Name: "users/get-user.ain", BackendInput: &data.BackendInput{
+			Host:    mustParseUrl(t, "https://example.com/users/1?fields=id&q=a+b%26c"),
+			Headers: []string{"Accept: application/json"},
+			Auth:    &data.Auth{Scheme: data.BasicAuthScheme, User: "admin", Password: "secret"},
+		}},
+		{Name: "users/api.ain#create-user", BackendInput: &data.BackendInput{
+			Host: mustParseUrl(t, "https://example.com/users"),
+			Body: []string{"name=ain"},
+			Auth: &data.Auth{Scheme: data.DigestAuthScheme, User: "admin", Password: "secret"},
+		}},
+		{Name: "upload.ain", BackendInput: &data.BackendInput{
+			Host:      mustParseUrl(t, "https://example.com/upload"),
+			Method:    "put",
+			Multipart: []data.MultipartField{{Name: "caption", Value: "cat"}, {Name: "photo", Filename: "cat.png", ContentType: "image/png"}},
+		}},
+	}
+
+	harBytes, notExported, err := ExportHar(exportedRequests, "1.6.0", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Read back by the import, it must open where HAR files do
+	importedFiles, _, err := ImportHar(harBytes)
+	if err != nil {
+		t.Fatalf("Unexpected error importing the export: %v", err)
+	}
+
+	expectedFiles := []data.ImportedFile{
+		{Filename: "_base.ain", Contents: "[Host]\nhttps://example.com\n\n[Backend]\ncurl\n"},
+		{Filename: "1-get-users-1.ain", Contents: "[Host]\n/users/1\n\n[Query]\nfields=id\nq=a+b%26c\n\n[Headers]\nAccept: application/json\nAuthorization: Basic YWRtaW46c2VjcmV0\n"},
+		{Filename: "2-post-users.ain", Contents: "[Host]\n/users\n\n[Headers]\nContent-Type: application/x-www-form-urlencoded\n\n[Method]\nPOST\n\n[Body]\nname=ain\n"},
+		{Filename: "3-put-upload.ain", Contents: "[Host]\n/upload\n\n[Method]\nPUT\n\n[Multipart]\ncaption=cat\nphoto=@cat.png;type=image/png\n"},
+	}
+
+	if !reflect.DeepEqual(importedFiles, expectedFiles) {
+		t.Errorf("Unexpected files: %+v", importedFiles)
+	}
+
+	expectedNotExported := []data.NotExported{
+		{Name: "users/api.ain#create-user", What: "digest auth"},
+	}
+
+	if !reflect.DeepEqual(notExported, expectedNotExported) {
+		t.Errorf("Unexpected not exported: %+v", notExported)
+	}
+}
+
+func Test_ExportPostman(t *testing.T) {
+	exportedRequests := []data.ExportedRequest{
+		{Name: "health.ain", BackendInput: &data.BackendInput{
+			Host: mustParseUrl(t, "https://example.com/health"),
+		}},
+		{Name: "users/api.ain#get-user", BackendInput: &data.BackendInput{
+			Host: mustParseUrl(t, "https://example.com/users/1?a=1&b=2"),
+			Auth: &data.Auth{Scheme: data.BearerAuthScheme, Token: "t0k3n"},
+		}},
+		{Name: "users/api.ain#create-user", BackendInput: &data.BackendInput{
+			Host:    mustParseUrl(t, "https://example.com/users"),
+			Headers: []string{"Content-Type: application/json; charset=utf-8"},
+			Body:    []string{"{", `  "tag": "#1"`, "}"},
+		}},
+	}
+
+	collectionBytes, notExported, err := ExportPostman("api", exportedRequests)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedCollection := `{
+  "info": {
+    "name": "api",
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+  },
+  "item": [
+    {
+      "name": "health",
+      "request": {
+        "method": "GET",
+        "header": [],
+        "url": "https://example.com/health"
+      }
+    },
+    {
+      "name": "users",
+      "item": [
+        {
+          "name": "api",
+          "item": [
+            {
+              "name": "get-user",
+              "request": {
+                "method": "GET",
+                "header": [],
+                "url": "https://example.com/users/1?a=1&b=2",
+                "auth": {
+                  "bearer": [
+                    {
+                      "key": "token",
+                      "value": "t0k3n",
+                      "type": "string"
+                    }
+                  ],
+                  "type": "bearer"
+                }
+              }
+            },
+            {
+              "name": "create-user",
+              "request": {
+                "method": "POST",
+                "header": [
+                  {
+                    "key": "Content-Type",
+                    "value": "application/json; charset=utf-8"
+                  }
+                ],
+                "url": "https://example.com/users",
+                "body": {
+                  "mode": "raw",
+                  "raw": "{\n  \"tag\": \"#1\"\n}",
+                  "options": {
+                    "raw": {
+                      "language": "json"
+                    }
+                  }
+                }
+              }
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+
+	if string(collectionBytes) != expectedCollection {
+		t.Errorf("Unexpected collection:\n%s", collectionBytes)
+	}
+
+	if len(notExported) != 0 {
+		t.Errorf("Unexpected not exported: %+v", notExported)
+	}
+
+	// Read back by the import, the templates are the same requests
+	importedFiles, _, err := ImportPostman(collectionBytes)
+	if err != nil {
+		t.Fatalf("Unexpected error importing the export: %v", err)
+	}
+
+	expectedFile := data.ImportedFile{Filename: "users/api/create-user.ain", Contents: "[Host]\nhttps://example.com/users\n\n[Headers]\nContent-Type: application/json; charset=utf-8\n\n[Method]\nPOST\n\n[Body]\n{\n  \"tag\": \"`#1\"\n}\n"}
+	if importedFiles[len(importedFiles)-1] != expectedFile {
+		t.Errorf("Unexpected imported file: %+v", importedFiles[len(importedFiles)-1])
+	}
+}
+
+func Test_ExportBodyFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	textFileName := filepath.Join(tmpDir, "body.json")
+	if err := os.WriteFile(textFileName, []byte(`{"id": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	binaryFileName := filepath.Join(tmpDir, "body.bin")
+	if err := os.WriteFile(binaryFileName, []byte{0xff, 0xfe}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	exportedRequests := []data.ExportedRequest{
+		{Name: "text.ain", BackendInput: &data.BackendInput{Host: mustParseUrl(t, "https://example.com"), Method: "PATCH", BodyFileName: textFileName}},
+		{Name: "binary.ain", BackendInput: &data.BackendInput{Host: mustParseUrl(t, "https://example.com"), BodyFileName: binaryFileName}},
+	}
+
+	notExported := []data.NotExported{}
+
+	textRequest, err := getExportedHttpRequest(exportedRequests[0], &notExported)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if textRequest.method != "PATCH" || textRequest.body != `{"id": 1}` || !textRequest.hasBody || textRequest.mimeType != defaultExportMimeType {
+		t.Errorf("Unexpected request: %+v", textRequest)
+	}
+
+	binaryRequest, err := getExportedHttpRequest(exportedRequests[1], &notExported)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if binaryRequest.method != "POST" || binaryRequest.hasBody {
+		t.Errorf("Unexpected request: %+v", binaryRequest)
+	}
+
+	expectedNotExported := []data.NotExported{{Name: "binary.ain", What: "the binary [BodyFile] " + binaryFileName}}
+	if !reflect.DeepEqual(notExported, expectedNotExported) {
+		t.Errorf("Unexpected not exported: %+v", notExported)
+	}
+}
+
+func Test_ExportBadCases(t *testing.T) {
+	tests := map[string]struct {
+		backendInput  *data.BackendInput
+		expectedError string
+	}{
+		"Malformed header": {
+			backendInput:  &data.BackendInput{Host: mustParseUrl(t, "https://example.com"), Headers: []string{"X-Debug"}},
+			expectedError: "malformed header in get.ain, missing colon: X-Debug",
+		},
+		"Missing body file": {
+			backendInput:  &data.BackendInput{Host: mustParseUrl(t, "https://example.com"), BodyFileName: "missing.json"},
+			expectedError: "could not read [BodyFile] missing.json: open missing.json: no such file or directory",
+		},
+	}
+
+	for name, test := range tests {
+		exportedRequests := []data.ExportedRequest{{Name: "get.ain", BackendInput: test.backendInput}}
+
+		if _, _, err := ExportHar(exportedRequests, "1.6.0", time.Now()); err == nil || err.Error() != test.expectedError {
+			t.Errorf("Test: %s. Unexpected HAR error: %v", name, err)
+		}
+
+		if _, _, err := ExportPostman("api", exportedRequests); err == nil || err.Error() != test.expectedError {
+			t.Errorf("Test: %s. Unexpected Postman error: %v", name, err)
+		}
+	}
+}
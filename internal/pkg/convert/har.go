@@ -0,0 +1,237 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/pkg/errors"
+)
+
+// Picked up by ain for all templates in the folder
+const baseTemplateFilename = "_base.ain"
+
+type harNameValue struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+}
+
+type harRequest struct {
+	Method   string         `json:"method"`
+	Url      string         `json:"url"`
+	Headers  []harNameValue `json:"headers"`
+	PostData *struct {
+		MimeType string         `json:"mimeType"`
+		Text     string         `json:"text"`
+		Params   []harNameValue `json:"params"`
+	} `json:"postData"`
+}
+
+type har struct {
+	Log struct {
+		Entries []struct {
+			Request harRequest `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// Set by the backend or not sent by HTTP/1.1, e g :authority in HTTP/2.
+// Accept-Encoding would have the response printed compressed.
+var skippedHarHeaders = []string{"Host", "Content-Length", "Connection", "Accept-Encoding"}
+
+var nonFilenameCharsRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Longer paths are cut, the file name is only a hint
+const maxFilenameLength = 60
+
+func isSkippedHarHeader(name string) bool {
+	if strings.HasPrefix(name, ":") {
+		return true
+	}
+
+	for _, skippedHarHeader := range skippedHarHeaders {
+		if strings.EqualFold(name, skippedHarHeader) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// E g Get all users -> get-all-users
+func getFilenameSlug(name string) string {
+	slug := nonFilenameCharsRe.ReplaceAllString(strings.ToLower(name), "-")
+	slug = strings.Trim(slug, "-")
+
+	if len(slug) > maxFilenameLength {
+		slug = strings.TrimRight(slug[:maxFilenameLength], "-")
+	}
+
+	return slug
+}
+
+// E g get-api-users.ain
+func getTemplateFilename(method, urlPath string) string {
+	return getFilenameSlug(method+" "+urlPath) + ".ain"
+}
+
+func getHarBody(request harRequest) (template, importNotes) {
+	t := template{}
+	notes := importNotes{}
+	postData := request.PostData
+
+	if postData == nil {
+		return t, notes
+	}
+
+	switch {
+	case postData.Text != "":
+		t.body = escapeLines(strings.Split(postData.Text, "\n"))
+
+	case strings.HasPrefix(postData.MimeType, "multipart/form-data"):
+		for _, param := range postData.Params {
+			if param.FileName == "" {
+				t.multipart = append(t.multipart, escape(param.Name+"="+param.Value))
+				continue
+			}
+
+			// The file contents are not in the HAR
+			multipartFile := param.Name + "=@" + param.FileName
+			if param.ContentType != "" {
+				multipartFile += ";type=" + param.ContentType
+			}
+
+			t.multipart = append(t.multipart, escape(multipartFile))
+			notes.add("the contents of the multipart file " + param.FileName)
+		}
+
+	default:
+		for _, param := range postData.Params {
+			t.form = append(t.form, escape(param.Name+"="+param.Value))
+		}
+	}
+
+	return t, notes
+}
+
+func getHarTemplate(request harRequest, requestUrl *url.URL) (template, importNotes) {
+	t, notes := getHarBody(request)
+
+	t.host = escape(requestUrl.EscapedPath())
+	if requestUrl.RawQuery != "" {
+		t.query = escapeLines(strings.Split(requestUrl.RawQuery, "&"))
+	}
+
+	for _, header := range request.Headers {
+		if isSkippedHarHeader(header.Name) {
+			continue
+		}
+
+		// The multipart boundary is generated anew
+		if len(t.multipart) > 0 && strings.EqualFold(header.Name, "Content-Type") {
+			continue
+		}
+
+		t.headers = append(t.headers, escape(header.Name+": "+header.Value))
+	}
+
+	if method := strings.ToUpper(request.Method); method != "GET" {
+		t.method = method
+	}
+
+	return t, notes
+}
+
+// ImportHar returns one template per request in the HAR and a
+// base template per scheme and host. With several hosts each
+// gets a folder of its own.
+func ImportHar(harBytes []byte) ([]data.ImportedFile, []data.NotImported, error) {
+	harFile := har{}
+	if err := json.Unmarshal(harBytes, &harFile); err != nil {
+		return nil, nil, errors.Wrap(err, "could not parse HAR file")
+	}
+
+	type hostTemplate struct {
+		filename string
+		template template
+		notes    importNotes
+	}
+
+	hostTemplates := map[string][]hostTemplate{}
+	hosts := []string{}
+
+	for i, entry := range harFile.Log.Entries {
+		requestUrl, err := url.Parse(entry.Request.Url)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "could not parse url of request %d in HAR file", i+1)
+		}
+
+		// E g data: and chrome-extension: urls
+		if requestUrl.Scheme != "http" && requestUrl.Scheme != "https" {
+			continue
+		}
+
+		host := requestUrl.Scheme + "://" + requestUrl.Host
+		if _, exists := hostTemplates[host]; !exists {
+			hosts = append(hosts, host)
+		}
+
+		t, notes := getHarTemplate(entry.Request, requestUrl)
+		hostTemplates[host] = append(hostTemplates[host], hostTemplate{
+			filename: getTemplateFilename(entry.Request.Method, requestUrl.Path),
+			template: t,
+			notes:    notes,
+		})
+	}
+
+	if len(hosts) == 0 {
+		return nil, nil, errors.New("found no http or https requests in HAR file")
+	}
+
+	sort.Strings(hosts)
+
+	importedFiles := []data.ImportedFile{}
+	notImported := []data.NotImported{}
+
+	takenFolders := map[string]bool{}
+
+	for _, host := range hosts {
+		folder := ""
+		if len(hosts) > 1 {
+			// Scheme included, the same host over http and https are two base templates
+			folder = getUniqueName(getFilenameSlug(host), "host", takenFolders)
+		}
+
+		baseTemplate := template{host: escape(host), backend: "curl"}
+		importedFiles = append(importedFiles, data.ImportedFile{
+			Filename: path.Join(folder, baseTemplateFilename),
+			Contents: baseTemplate.String(),
+		})
+
+		// Numbered so the files list in the order they were recorded
+		numberWidth := len(strconv.Itoa(len(hostTemplates[host])))
+
+		for i, hostTemplate := range hostTemplates[host] {
+			filename := fmt.Sprintf("%0*d-%s", numberWidth, i+1, hostTemplate.filename)
+
+			importedFiles = append(importedFiles, data.ImportedFile{
+				Filename: path.Join(folder, filename),
+				Contents: hostTemplate.template.String(),
+			})
+
+			for _, note := range hostTemplate.notes {
+				notImported = append(notImported, data.NotImported{Filename: path.Join(folder, filename), What: note})
+			}
+		}
+	}
+
+	return importedFiles, notImported, nil
+}
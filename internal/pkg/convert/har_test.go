@@ -0,0 +1,123 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_ImportHar(t *testing.T) {
+	harJson := `{"log": {"version": "1.2", "entries": [
+		{"request": {"method": "GET", "url": "https://api.example.com/users?page=1&q=a%20b", "headers": [
+			{"name": ":authority", "value": "api.example.com"},
+			{"name": "Host", "value": "api.example.com"},
+			{"name": "Accept-Encoding", "value": "gzip, deflate, br"},
+			{"name": "Accept", "value": "application/json"}
+		]}},
+		{"request": {"method": "post", "url": "https://api.example.com/users", "headers": [
+			{"name": "Content-Type", "value": "application/json"},
+			{"name": "Content-Length", "value": "16"}
+		], "postData": {"mimeType": "application/json", "text": "{\n  \"id\": \"#1\"\n}"}}},
+		{"request": {"method": "POST", "url": "http://auth.example.com:8080/login", "headers": [], "postData": {
+			"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "user", "value": "ain"}]
+		}}},
+		{"request": {"method": "POST", "url": "http://auth.example.com:8080/avatar", "headers": [
+			{"name": "Content-Type", "value": "multipart/form-data; boundary=x"}
+		], "postData": {"mimeType": "multipart/form-data; boundary=x", "params": [
+			{"name": "name", "value": "ain"},
+			{"name": "avatar", "fileName": "avatar.png", "contentType": "image/png"}
+		]}}},
+		{"request": {"method": "GET", "url": "data:image/png;base64,AAAA", "headers": []}}
+	]}}`
+
+	importedFiles, notImported, err := ImportHar([]byte(harJson))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedFiles := []data.ImportedFile{
+		{Filename: "http-auth-example-com-8080/_base.ain", Contents: "[Host]\nhttp://auth.example.com:8080\n\n[Backend]\ncurl\n"},
+		{Filename: "http-auth-example-com-8080/1-post-login.ain", Contents: "[Host]\n/login\n\n[Method]\nPOST\n\n[Form]\nuser=ain\n"},
+		{Filename: "http-auth-example-com-8080/2-post-avatar.ain", Contents: "[Host]\n/avatar\n\n[Method]\nPOST\n\n[Multipart]\nname=ain\navatar=@avatar.png;type=image/png\n"},
+		{Filename: "https-api-example-com/_base.ain", Contents: "[Host]\nhttps://api.example.com\n\n[Backend]\ncurl\n"},
+		{Filename: "https-api-example-com/1-get-users.ain", Contents: "[Host]\n/users\n\n[Query]\npage=1\nq=a%20b\n\n[Headers]\nAccept: application/json\n"},
+		{Filename: "https-api-example-com/2-post-users.ain", Contents: "[Host]\n/users\n\n[Headers]\nContent-Type: application/json\n\n[Method]\nPOST\n\n[Body]\n{\n  \"id\": \"`#1\"\n}\n"},
+	}
+
+	if !reflect.DeepEqual(importedFiles, expectedFiles) {
+		t.Errorf("Unexpected files: %+v", importedFiles)
+	}
+
+	expectedNotImported := []data.NotImported{
+		{Filename: "http-auth-example-com-8080/2-post-avatar.ain", What: "the contents of the multipart file avatar.png"},
+	}
+
+	if !reflect.DeepEqual(notImported, expectedNotImported) {
+		t.Errorf("Unexpected not imported: %+v", notImported)
+	}
+}
+
+func Test_ImportHarHostFolders(t *testing.T) {
+	harJson := `{"log": {"entries": [
+		{"request": {"method": "GET", "url": "https://api.example.com/", "headers": []}},
+		{"request": {"method": "GET", "url": "http://api.example.com/", "headers": []}},
+		{"request": {"method": "GET", "url": "https://api-example.com/", "headers": []}}
+	]}}`
+
+	importedFiles, _, err := ImportHar([]byte(harJson))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	filenames := []string{}
+	for _, importedFile := range importedFiles {
+		filenames = append(filenames, importedFile.Filename)
+	}
+
+	expectedFilenames := []string{
+		"http-api-example-com/_base.ain",
+		"http-api-example-com/1-get.ain",
+		"https-api-example-com/_base.ain",
+		"https-api-example-com/1-get.ain",
+		"https-api-example-com-2/_base.ain",
+		"https-api-example-com-2/1-get.ain",
+	}
+
+	if !reflect.DeepEqual(filenames, expectedFilenames) {
+		t.Errorf("Unexpected filenames: %v", filenames)
+	}
+}
+
+func Test_ImportHarBadCases(t *testing.T) {
+	tests := map[string]struct {
+		harJson       string
+		expectedError string
+	}{
+		"Not JSON": {
+			harJson:       "<html>",
+			expectedError: "could not parse HAR file: invalid character '<' looking for beginning of value",
+		},
+		"No requests": {
+			harJson:       `{"log": {"entries": [{"request": {"method": "GET", "url": "data:,"}}]}}`,
+			expectedError: "found no http or https requests in HAR file",
+		},
+	}
+
+	for name, test := range tests {
+		_, _, err := ImportHar([]byte(test.harJson))
+		if err == nil || err.Error() != test.expectedError {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+		}
+	}
+}
+
+func Test_getTemplateFilename(t *testing.T) {
+	if filename := getTemplateFilename("GET", "/"); filename != "get.ain" {
+		t.Errorf("Unexpected filename: %s", filename)
+	}
+
+	if filename := getTemplateFilename("DELETE", "/api/v1/Users/42/"); filename != "delete-api-v1-users-42.ain" {
+		t.Errorf("Unexpected filename: %s", filename)
+	}
+}
@@ -0,0 +1,398 @@
+package convert
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/pkg/errors"
+)
+
+type insomniaParam struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+	Type     string `json:"type"`
+	FileName string `json:"fileName"`
+}
+
+type insomniaAuth struct {
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
+	Prefix   string `json:"prefix"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	AddTo    string `json:"addTo"`
+}
+
+// All of workspaces, folders, requests and environments
+type insomniaResource struct {
+	Id                  string                     `json:"_id"`
+	Type                string                     `json:"_type"`
+	ParentId            string                     `json:"parentId"`
+	Name                string                     `json:"name"`
+	MetaSortKey         float64                    `json:"metaSortKey"`
+	Method              string                     `json:"method"`
+	Url                 string                     `json:"url"`
+	Headers             []insomniaParam            `json:"headers"`
+	Parameters          []insomniaParam            `json:"parameters"`
+	Authentication      *insomniaAuth              `json:"authentication"`
+	PreRequestScript    string                     `json:"preRequestScript"`
+	AfterResponseScript string                     `json:"afterResponseScript"`
+	Environment         map[string]json.RawMessage `json:"environment"`
+	Data                map[string]json.RawMessage `json:"data"`
+	Body                struct {
+		MimeType string          `json:"mimeType"`
+		Text     string          `json:"text"`
+		Params   []insomniaParam `json:"params"`
+		FileName string          `json:"fileName"`
+	} `json:"body"`
+}
+
+type insomniaExport struct {
+	Type         string             `json:"_type"`
+	ExportFormat int                `json:"__export_format"`
+	Resources    []insomniaResource `json:"resources"`
+}
+
+// {{ _.var }}, {{ var }} and tags such as {% response ... %}
+var insomniaPlaceholderRe = regexp.MustCompile(`\{\{[^{}]*\}\}|\{%.*?%\}`)
+
+var insomniaVarNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Not requests, but nothing to import either
+var skippedInsomniaResourceTypes = []string{"cookie_jar", "api_spec", "proto_file", "proto_directory"}
+
+func getInsomniaVarName(placeholder string) (string, string) {
+	if strings.HasPrefix(placeholder, "{%") {
+		tagName := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(placeholder, "{%"), "%}"))
+		if len(tagName) == 0 {
+			return "", "the empty tag " + placeholder
+		}
+
+		return "", "the " + tagName[0] + " tag"
+	}
+
+	name := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(placeholder, "{{"), "}}"))
+	name = strings.TrimPrefix(name, "_.")
+
+	// E g filters such as {{ name | upper }}
+	if !insomniaVarNameRe.MatchString(name) {
+		return "", "the expression " + placeholder
+	}
+
+	return name, ""
+}
+
+func translateInsomniaVars(text string, notes *importNotes) string {
+	return translateVars(text, insomniaPlaceholderRe, getInsomniaVarName, notes)
+}
+
+// Nested objects are flattened, {{ _.db.host }} is then ${db_host}
+func addInsomniaVars(vars *[][]string, prefix string, environment map[string]json.RawMessage, notes *importNotes) {
+	names := []string{}
+	for name := range environment {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		rawValue := environment[name]
+
+		nestedEnvironment := map[string]json.RawMessage{}
+		if err := json.Unmarshal(rawValue, &nestedEnvironment); err == nil {
+			addInsomniaVars(vars, prefix+name+".", nestedEnvironment, notes)
+			continue
+		}
+
+		value := ""
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			value = string(rawValue)
+		}
+
+		if insomniaPlaceholderRe.MatchString(value) {
+			notes.add("the variables or tags used in the value of " + prefix + name)
+		}
+
+		*vars = append(*vars, []string{getVarName(prefix + name), value})
+	}
+}
+
+func getInsomniaVars(environment map[string]json.RawMessage, notes *importNotes) [][]string {
+	vars := [][]string{}
+	addInsomniaVars(&vars, "", environment, notes)
+
+	return vars
+}
+
+// Returns the [Auth] line, empty if it can't be imported
+func getInsomniaAuth(t *template, auth *insomniaAuth, notes *importNotes) string {
+	switch auth.Type {
+	case "basic", "digest":
+		return translateInsomniaVars(auth.Type+" "+auth.Username+":"+auth.Password, notes)
+
+	case "bearer":
+		if auth.Prefix != "" && !strings.EqualFold(auth.Prefix, "bearer") {
+			t.headers = append(t.headers, translateInsomniaVars("Authorization: "+auth.Prefix+" "+auth.Token, notes))
+			return ""
+		}
+
+		return translateInsomniaVars("bearer "+auth.Token, notes)
+
+	case "apikey":
+		switch auth.AddTo {
+		case "queryParams":
+			return translateInsomniaVars("apikey query "+auth.Key+" "+auth.Value, notes)
+		case "", "header":
+			return translateInsomniaVars("apikey header "+auth.Key+" "+auth.Value, notes)
+		}
+
+		notes.add("the api key added to " + auth.AddTo)
+		return ""
+	}
+
+	notes.add(auth.Type + " auth")
+	return ""
+}
+
+// None removes any inherited auth and an empty auth inherits it
+func setInsomniaAuth(t *template, auth *insomniaAuth, inheritedAuth string, notes *importNotes) {
+	switch {
+	case auth == nil || auth.Disabled || auth.Type == "" || auth.Type == "inherit":
+
+	case auth.Type == "none":
+		if inheritedAuth != "" {
+			removedHeaders, removedQuery := getAuthRemovals(inheritedAuth)
+			t.headers = append(t.headers, removedHeaders...)
+			t.query = append(t.query, removedQuery...)
+		}
+
+	default:
+		t.auth = getInsomniaAuth(t, auth, notes)
+	}
+}
+
+// Disabled lines are kept as comments
+func getInsomniaParamLines(params []insomniaParam, separator string, notes *importNotes) []string {
+	lines := []string{}
+
+	for _, param := range params {
+		line := translateInsomniaVars(param.Name+separator+param.Value, notes)
+
+		if param.Type == "file" {
+			line = translateInsomniaVars(param.Name, notes) + "=@" + escape(param.FileName)
+		}
+
+		if param.Disabled {
+			line = "# " + line
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func setInsomniaBody(t *template, resource insomniaResource, notes *importNotes) {
+	body := resource.Body
+
+	switch {
+	case body.MimeType == "multipart/form-data":
+		t.multipart = getInsomniaParamLines(body.Params, "=", notes)
+
+	case body.MimeType == "application/x-www-form-urlencoded":
+		t.form = getInsomniaParamLines(body.Params, "=", notes)
+
+	case body.MimeType == "application/graphql":
+		graphQLBody := struct {
+			Query     string          `json:"query"`
+			Variables json.RawMessage `json:"variables"`
+		}{}
+
+		if err := json.Unmarshal([]byte(body.Text), &graphQLBody); err != nil {
+			notes.add("the GraphQL body, it is not valid JSON")
+			return
+		}
+
+		for _, line := range strings.Split(graphQLBody.Query, "\n") {
+			t.graphQL = append(t.graphQL, translateInsomniaVars(line, notes))
+		}
+
+		if variables := strings.TrimSpace(string(graphQLBody.Variables)); variables != "" && variables != "null" && variables != "{}" {
+			t.graphQLVars = []string{translateInsomniaVars(variables, notes)}
+		}
+
+	case body.FileName != "":
+		t.bodyFile = escape(body.FileName)
+
+	case body.Text != "":
+		for _, line := range strings.Split(body.Text, "\n") {
+			t.body = append(t.body, translateInsomniaVars(line, notes))
+		}
+	}
+
+	hasBody := len(t.body) > 0 || t.bodyFile != ""
+	if hasBody && body.MimeType != "" && !hasHeader(t.headers, "Content-Type") {
+		t.headers = append(t.headers, "Content-Type: "+body.MimeType)
+	}
+}
+
+func getInsomniaTemplate(resource insomniaResource, inheritedAuth string, notes *importNotes) template {
+	host, rawQuery, _ := strings.Cut(resource.Url, "?")
+
+	t := template{host: translateInsomniaVars(host, notes)}
+
+	if rawQuery != "" {
+		for _, queryLine := range strings.Split(rawQuery, "&") {
+			t.query = append(t.query, translateInsomniaVars(queryLine, notes))
+		}
+	}
+
+	t.query = append(t.query, getInsomniaParamLines(resource.Parameters, "=", notes)...)
+
+	headers := []insomniaParam{}
+	for _, header := range resource.Headers {
+		// The multipart boundary is generated anew
+		if resource.Body.MimeType == "multipart/form-data" && strings.EqualFold(header.Name, "Content-Type") {
+			continue
+		}
+
+		headers = append(headers, header)
+	}
+
+	t.headers = getInsomniaParamLines(headers, ": ", notes)
+
+	if method := strings.ToUpper(resource.Method); method != "" && method != "GET" {
+		t.method = method
+	}
+
+	setInsomniaBody(&t, resource, notes)
+	setInsomniaAuth(&t, resource.Authentication, inheritedAuth, notes)
+
+	if strings.TrimSpace(resource.PreRequestScript) != "" {
+		notes.add("pre-request script")
+	}
+
+	if strings.TrimSpace(resource.AfterResponseScript) != "" {
+		notes.add("after-response script")
+	}
+
+	return t
+}
+
+func isSkippedInsomniaResourceType(resourceType string) bool {
+	for _, skippedResourceType := range skippedInsomniaResourceTypes {
+		if resourceType == skippedResourceType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getInsomniaFolder(resource insomniaResource, children map[string][]insomniaResource, inheritedAuth string) *collectionFolder {
+	folder := &collectionFolder{name: resource.Name}
+
+	setInsomniaAuth(&folder.baseTemplate, resource.Authentication, inheritedAuth, &folder.notImported)
+	if resource.Authentication != nil && resource.Authentication.Type == "none" {
+		inheritedAuth = ""
+	} else if folder.baseTemplate.auth != "" {
+		inheritedAuth = folder.baseTemplate.auth
+	}
+
+	folder.vars = getInsomniaVars(resource.Environment, &folder.notImported)
+
+	if strings.TrimSpace(resource.PreRequestScript) != "" {
+		folder.notImported.add("pre-request script")
+	}
+
+	for _, child := range children[resource.Id] {
+		switch {
+		case child.Type == "request_group":
+			folder.folders = append(folder.folders, getInsomniaFolder(child, children, inheritedAuth))
+
+		case child.Type == "request":
+			request := collectionRequest{name: child.Name}
+			request.template = getInsomniaTemplate(child, inheritedAuth, &request.notImported)
+			folder.requests = append(folder.requests, request)
+
+		// The base environment of a workspace, its sub environments are selected with -e
+		case child.Type == "environment":
+			folder.vars = mergeVars(folder.vars, getInsomniaVars(child.Data, &folder.notImported))
+
+			for _, subEnvironment := range children[child.Id] {
+				folder.envFiles = append(folder.envFiles, collectionEnvFile{
+					name: subEnvironment.Name,
+					vars: getInsomniaVars(subEnvironment.Data, &folder.notImported),
+				})
+			}
+
+		case isSkippedInsomniaResourceType(child.Type):
+
+		default:
+			folder.notImported.add("the " + strings.ReplaceAll(child.Type, "_", " ") + " " + child.Name)
+		}
+	}
+
+	return folder
+}
+
+// ImportInsomnia returns the templates of an Insomnia v4 export laid
+// out as its folders, together with what could not be imported. Several
+// workspaces get a folder each.
+func ImportInsomnia(exportBytes []byte) ([]data.ImportedFile, []data.NotImported, error) {
+	export := insomniaExport{}
+	if err := json.Unmarshal(exportBytes, &export); err != nil {
+		return nil, nil, errors.Wrap(err, "could not parse Insomnia export")
+	}
+
+	if export.Type != "export" {
+		return nil, nil, errors.New("not an Insomnia export, missing _type export")
+	}
+
+	if export.ExportFormat != 4 {
+		return nil, nil, errors.Errorf("only Insomnia exports in format 4 can be imported, got format %d", export.ExportFormat)
+	}
+
+	children := map[string][]insomniaResource{}
+	workspaces := []insomniaResource{}
+
+	for _, resource := range export.Resources {
+		if resource.Type == "workspace" {
+			workspaces = append(workspaces, resource)
+			continue
+		}
+
+		children[resource.ParentId] = append(children[resource.ParentId], resource)
+	}
+
+	// Same order as in the sidebar
+	for _, siblings := range children {
+		sort.SliceStable(siblings, func(i, j int) bool {
+			return siblings[i].MetaSortKey < siblings[j].MetaSortKey
+		})
+	}
+
+	if len(workspaces) == 0 {
+		return nil, nil, errors.New("found no workspace in Insomnia export")
+	}
+
+	topFolder := &collectionFolder{}
+	if len(workspaces) == 1 {
+		topFolder = getInsomniaFolder(workspaces[0], children, "")
+	} else {
+		for _, workspace := range workspaces {
+			topFolder.folders = append(topFolder.folders, getInsomniaFolder(workspace, children, ""))
+		}
+	}
+
+	importedFiles, notImported := topFolder.getImportedFiles()
+
+	return importedFiles, notImported, nil
+}
@@ -0,0 +1,99 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_ImportInsomnia(t *testing.T) {
+	exportJson := `{"_type": "export", "__export_format": 4, "resources": [
+		{"_id": "wrk_1", "_type": "workspace", "parentId": null, "name": "Shop"},
+		{"_id": "env_base", "_type": "environment", "parentId": "wrk_1", "name": "Base Environment", "data": {"base_url": "http://localhost:8080", "db": {"user": "admin"}, "port": 8080}},
+		{"_id": "env_prod", "_type": "environment", "parentId": "env_base", "name": "Production", "data": {"base_url": "https://shop.example.com"}},
+		{"_id": "fld_1", "_type": "request_group", "parentId": "wrk_1", "name": "Orders", "environment": {"order_id": "7"},
+			"authentication": {"type": "basic", "username": "{{ _.db.user }}", "password": "p#ss"}},
+		{"_id": "req_2", "_type": "request", "parentId": "fld_1", "metaSortKey": 2, "name": "Upload receipt", "method": "POST",
+			"url": "{{ _.base_url }}/orders/{{ order_id }}/receipt",
+			"headers": [{"name": "Content-Type", "value": "multipart/form-data"}],
+			"body": {"mimeType": "multipart/form-data", "params": [{"name": "note", "value": "paid"}, {"name": "file", "type": "file", "fileName": "receipt.pdf"}]},
+			"authentication": {}},
+		{"_id": "req_1", "_type": "request", "parentId": "fld_1", "metaSortKey": 1, "name": "Get order", "method": "GET",
+			"url": "{{ _.base_url }}/orders/{{ _.order_id }}?expand=items",
+			"parameters": [{"name": "fields", "value": "id"}, {"name": "debug", "value": "1", "disabled": true}],
+			"headers": [{"name": "X-Token", "value": "{% response 'body', 'req_x', 'b64::JC50b2tlbg==::46b', 'never', 60 %}"}],
+			"authentication": {"type": "none"}, "preRequestScript": "insomnia.request.addHeader('a', 'b')"},
+		{"_id": "req_3", "_type": "request", "parentId": "wrk_1", "metaSortKey": 3, "name": "Search", "method": "POST",
+			"url": "{{ _.base_url }}/graphql",
+			"body": {"mimeType": "application/graphql", "text": "{\"query\":\"{ search { id } }\",\"variables\":{\"q\":\"ain\"}}"},
+			"authentication": {"type": "bearer", "token": "{{ _.token | upper }}"}},
+		{"_id": "req_4", "_type": "request", "parentId": "wrk_1", "metaSortKey": 4, "name": "Create order", "method": "POST",
+			"url": "{{ _.base_url }}/orders", "body": {"mimeType": "application/json", "text": "{\n  \"id\": 1\n}"}},
+		{"_id": "ws_1", "_type": "websocket_request", "parentId": "wrk_1", "name": "Live"},
+		{"_id": "jar_1", "_type": "cookie_jar", "parentId": "wrk_1", "name": "Default Jar"}
+	]}`
+
+	importedFiles, notImported, err := ImportInsomnia([]byte(exportJson))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedFiles := []data.ImportedFile{
+		{Filename: "_base.ain", Contents: "[Backend]\ncurl\n"},
+		{Filename: ".env", Contents: "base_url=\"http://localhost:8080\"\ndb_user=\"admin\"\nport=\"8080\"\n"},
+		{Filename: ".env.production", Contents: "base_url=\"https://shop.example.com\"\ndb_user=\"admin\"\nport=\"8080\"\n"},
+		{Filename: "search.ain", Contents: "[Host]\n${base_url}/graphql\n\n[Method]\nPOST\n\n[Auth]\nbearer {{ _.token | upper }}\n\n[GraphQL]\n{ search { id } }\n\n[GraphQLVariables]\n{\"q\":\"ain\"}\n"},
+		{Filename: "create-order.ain", Contents: "[Host]\n${base_url}/orders\n\n[Headers]\nContent-Type: application/json\n\n[Method]\nPOST\n\n[Body]\n{\n  \"id\": 1\n}\n"},
+		{Filename: "orders/_base.ain", Contents: "[Auth]\nbasic ${db_user}:p`#ss\n"},
+		{Filename: "orders/.env", Contents: "base_url=\"http://localhost:8080\"\ndb_user=\"admin\"\nport=\"8080\"\norder_id=\"7\"\n"},
+		{Filename: "orders/get-order.ain", Contents: "[Host]\n${base_url}/orders/${order_id}\n\n[Query]\nexpand=items\nfields=id\n# debug=1\n\n[Headers]\nX-Token: {% response 'body', 'req_x', 'b64::JC50b2tlbg==::46b', 'never', 60 %}\n-Authorization\n"},
+		{Filename: "orders/upload-receipt.ain", Contents: "[Host]\n${base_url}/orders/${order_id}/receipt\n\n[Method]\nPOST\n\n[Multipart]\nnote=paid\nfile=@receipt.pdf\n"},
+	}
+
+	if !reflect.DeepEqual(importedFiles, expectedFiles) {
+		t.Errorf("Unexpected files: %+v", importedFiles)
+	}
+
+	expectedNotImported := []data.NotImported{
+		{Filename: "", What: "the websocket request Live"},
+		{Filename: "search.ain", What: "the expression {{ _.token | upper }}"},
+		{Filename: "orders/get-order.ain", What: "the response tag"},
+		{Filename: "orders/get-order.ain", What: "pre-request script"},
+	}
+
+	if !reflect.DeepEqual(notImported, expectedNotImported) {
+		t.Errorf("Unexpected not imported: %+v", notImported)
+	}
+}
+
+func Test_ImportInsomniaBadCases(t *testing.T) {
+	tests := map[string]struct {
+		exportJson    string
+		expectedError string
+	}{
+		"Not JSON": {
+			exportJson:    "<html>",
+			expectedError: "could not parse Insomnia export: invalid character '<' looking for beginning of value",
+		},
+		"Not an export": {
+			exportJson:    `{"info": {}}`,
+			expectedError: "not an Insomnia export, missing _type export",
+		},
+		"Other format": {
+			exportJson:    `{"_type": "export", "__export_format": 3, "resources": []}`,
+			expectedError: "only Insomnia exports in format 4 can be imported, got format 3",
+		},
+		"No workspace": {
+			exportJson:    `{"_type": "export", "__export_format": 4, "resources": []}`,
+			expectedError: "found no workspace in Insomnia export",
+		},
+	}
+
+	for name, test := range tests {
+		_, _, err := ImportInsomnia([]byte(test.exportJson))
+		if err == nil || err.Error() != test.expectedError {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,579 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Used by the starter template as well
+const defaultOpenApiHost = "http://localhost:${PORT}"
+
+// Same order as in the OpenAPI specification
+var openApiMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Set by the backend, described in the spec as parameters for documentation only
+var skippedOpenApiHeaders = []string{"Accept", "Content-Type", "Authorization"}
+
+var openApiPathParamRe = regexp.MustCompile(`\{([^{}]+)\}`)
+
+var camelCaseRe = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// The spec is read as plain maps and lists so $refs
+// can point anywhere, with or without a schema
+type openApiSpec struct {
+	root map[string]interface{}
+}
+
+type openApiParameter struct {
+	name     string
+	in       string
+	required bool
+	value    string
+}
+
+// YAML can have keys that are not strings, JSON can't
+func normalizeYamlValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, mapValue := range typedValue {
+			typedValue[key] = normalizeYamlValue(mapValue)
+		}
+
+	case map[interface{}]interface{}:
+		stringKeyMap := map[string]interface{}{}
+		for key, mapValue := range typedValue {
+			stringKeyMap[fmt.Sprint(key)] = normalizeYamlValue(mapValue)
+		}
+
+		return stringKeyMap
+
+	case []interface{}:
+		for i, listValue := range typedValue {
+			typedValue[i] = normalizeYamlValue(listValue)
+		}
+	}
+
+	return value
+}
+
+func getOpenApiMap(node map[string]interface{}, key string) map[string]interface{} {
+	mapValue, _ := node[key].(map[string]interface{})
+	return mapValue
+}
+
+func getOpenApiString(node map[string]interface{}, key string) string {
+	stringValue, _ := node[key].(string)
+	return stringValue
+}
+
+func getSortedKeys(node map[string]interface{}) []string {
+	keys := []string{}
+	for key := range node {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Follows any $ref, also $refs pointing to other $refs
+func (s openApiSpec) resolve(node interface{}) (map[string]interface{}, error) {
+	mapNode, _ := node.(map[string]interface{})
+
+	for seenRefs := map[string]bool{}; mapNode != nil; {
+		ref, isRef := mapNode["$ref"].(string)
+		if !isRef {
+			break
+		}
+
+		if !strings.HasPrefix(ref, "#/") {
+			return nil, errors.Errorf("cannot resolve $ref %s, only refs within the spec are supported", ref)
+		}
+
+		if seenRefs[ref] {
+			return nil, errors.Errorf("cannot resolve $ref %s, it refers to itself", ref)
+		}
+
+		seenRefs[ref] = true
+
+		var refNode interface{} = s.root
+		for _, refPathPart := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			refPathPart = strings.NewReplacer("~1", "/", "~0", "~").Replace(refPathPart)
+
+			parentNode, _ := refNode.(map[string]interface{})
+			refNode = parentNode[refPathPart]
+		}
+
+		if refNode == nil {
+			return nil, errors.Errorf("cannot resolve $ref %s, found nothing there", ref)
+		}
+
+		mapNode, _ = refNode.(map[string]interface{})
+	}
+
+	return mapNode, nil
+}
+
+func getOpenApiRef(node interface{}) (string, bool) {
+	mapNode, _ := node.(map[string]interface{})
+	ref, isRef := mapNode["$ref"].(string)
+	return ref, isRef
+}
+
+// True if the schema has an example, default or enum value of its own
+func (s openApiSpec) hasSchemaExample(schemaNode interface{}) bool {
+	schema, err := s.resolve(schemaNode)
+	if err != nil || schema == nil {
+		return false
+	}
+
+	_, hasExample := schema["example"]
+	_, hasDefault := schema["default"]
+	enum, _ := schema["enum"].([]interface{})
+
+	return hasExample || hasDefault || len(enum) > 0
+}
+
+func containsOpenApiValue(values []interface{}, wantedValue string) bool {
+	for _, value := range values {
+		if value == wantedValue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// E g userId -> USER_ID
+func getOpenApiVarName(name string) string {
+	return getVarName(strings.ToUpper(camelCaseRe.ReplaceAllString(name, "${1}_${2}")))
+}
+
+func formatOpenApiValue(value interface{}) string {
+	if stringValue, isString := value.(string); isString {
+		return stringValue
+	}
+
+	valueBytes, _ := json.Marshal(value)
+	return string(valueBytes)
+}
+
+// Any example, default or enum value. Returns false if there's none.
+// The refPath holds the $refs followed to get here, a schema referring
+// back to one of them (e g a Pet with an owner Pet) is not expanded again.
+func (s openApiSpec) getSchemaExample(schemaNode interface{}, refPath map[string]bool) (interface{}, bool) {
+	if ref, isRef := getOpenApiRef(schemaNode); isRef {
+		if refPath[ref] {
+			return nil, false
+		}
+
+		refPath[ref] = true
+		defer delete(refPath, ref)
+	}
+
+	schema, err := s.resolve(schemaNode)
+	if err != nil || schema == nil {
+		return nil, false
+	}
+
+	for _, exampleKey := range []string{"example", "default"} {
+		if example, exists := schema[exampleKey]; exists {
+			return example, true
+		}
+	}
+
+	if enum, _ := schema["enum"].([]interface{}); len(enum) > 0 {
+		return enum[0], true
+	}
+
+	for _, combinerKey := range []string{"oneOf", "anyOf"} {
+		if subSchemas, _ := schema[combinerKey].([]interface{}); len(subSchemas) > 0 {
+			return s.getSchemaExample(subSchemas[0], refPath)
+		}
+	}
+
+	if allOf, _ := schema["allOf"].([]interface{}); len(allOf) > 0 {
+		mergedExample := map[string]interface{}{}
+		for _, subSchema := range allOf {
+			if example, ok := s.getSchemaExample(subSchema, refPath); ok {
+				if exampleObject, isObject := example.(map[string]interface{}); isObject {
+					for key, value := range exampleObject {
+						mergedExample[key] = value
+					}
+				}
+			}
+		}
+
+		return mergedExample, true
+	}
+
+	schemaType := getOpenApiString(schema, "type")
+
+	switch {
+	case schemaType == "object" || getOpenApiMap(schema, "properties") != nil:
+		requiredProperties, _ := schema["required"].([]interface{})
+
+		// Properties without an example of their own are only made up if required
+		example := map[string]interface{}{}
+		for propertyName, property := range getOpenApiMap(schema, "properties") {
+			if !s.hasSchemaExample(property) && !containsOpenApiValue(requiredProperties, propertyName) {
+				continue
+			}
+
+			if propertyExample, ok := s.getSchemaExample(property, refPath); ok {
+				example[propertyName] = propertyExample
+			}
+		}
+
+		return example, true
+
+	case schemaType == "array":
+		example := []interface{}{}
+		if itemExample, ok := s.getSchemaExample(schema["items"], refPath); ok {
+			example = append(example, itemExample)
+		}
+
+		return example, true
+
+	case schemaType == "string":
+		return "", true
+
+	case schemaType == "integer" || schemaType == "number":
+		return 0, true
+
+	case schemaType == "boolean":
+		return false, true
+	}
+
+	return nil, false
+}
+
+// Prefers example over examples over the schema
+func (s openApiSpec) getExample(node map[string]interface{}) (interface{}, bool, error) {
+	if example, exists := node["example"]; exists {
+		return example, true, nil
+	}
+
+	examples := getOpenApiMap(node, "examples")
+	if len(examples) > 0 {
+		example, err := s.resolve(examples[getSortedKeys(examples)[0]])
+		if err != nil {
+			return nil, false, err
+		}
+
+		if value, exists := example["value"]; exists {
+			return value, true, nil
+		}
+	}
+
+	example, ok := s.getSchemaExample(node["schema"], map[string]bool{})
+	return example, ok, nil
+}
+
+// Operation parameters override path parameters with the same name and location
+func (s openApiSpec) getParameters(pathItem, operation map[string]interface{}) ([]openApiParameter, error) {
+	parameters := []openApiParameter{}
+	parameterIndexes := map[string]int{}
+
+	pathParameters, _ := pathItem["parameters"].([]interface{})
+	operationParameters, _ := operation["parameters"].([]interface{})
+
+	for _, parameterNode := range append(pathParameters, operationParameters...) {
+		parameter, err := s.resolve(parameterNode)
+		if err != nil {
+			return nil, err
+		}
+
+		name, in := getOpenApiString(parameter, "name"), getOpenApiString(parameter, "in")
+		required, _ := parameter["required"].(bool)
+
+		value := "${" + getOpenApiVarName(name) + "}"
+		if example, ok, err := s.getExample(parameter); err != nil {
+			return nil, err
+		} else if ok && in != "path" && formatOpenApiValue(example) != "" {
+			value = escape(formatOpenApiValue(example))
+		}
+
+		openApiParameter := openApiParameter{name: name, in: in, required: required || in == "path", value: value}
+
+		if i, exists := parameterIndexes[in+" "+name]; exists {
+			parameters[i] = openApiParameter
+			continue
+		}
+
+		parameterIndexes[in+" "+name] = len(parameters)
+		parameters = append(parameters, openApiParameter)
+	}
+
+	return parameters, nil
+}
+
+// JSON first as it's what most APIs take
+func getOpenApiMediaType(content map[string]interface{}) string {
+	mediaTypes := getSortedKeys(content)
+
+	for _, mediaType := range mediaTypes {
+		if mediaType == "application/json" {
+			return mediaType
+		}
+	}
+
+	for _, mediaType := range mediaTypes {
+		if strings.Contains(mediaType, "json") {
+			return mediaType
+		}
+	}
+
+	return mediaTypes[0]
+}
+
+func formatJsonExample(example interface{}) []string {
+	var exampleBytes bytes.Buffer
+
+	encoder := json.NewEncoder(&exampleBytes)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(example)
+
+	return escapeLines(strings.Split(strings.TrimSuffix(exampleBytes.String(), "\n"), "\n"))
+}
+
+func (s openApiSpec) setOpenApiBody(t *template, operation map[string]interface{}) error {
+	requestBody, err := s.resolve(operation["requestBody"])
+	if err != nil || requestBody == nil {
+		return err
+	}
+
+	content := getOpenApiMap(requestBody, "content")
+	if len(content) == 0 {
+		return nil
+	}
+
+	mediaType := getOpenApiMediaType(content)
+	media := getOpenApiMap(content, mediaType)
+
+	example, hasExample, err := s.getExample(media)
+	if err != nil || !hasExample {
+		return err
+	}
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data":
+		exampleObject, _ := example.(map[string]interface{})
+		schema, err := s.resolve(media["schema"])
+		if err != nil {
+			return err
+		}
+
+		fields := []string{}
+		for _, fieldName := range getSortedKeys(exampleObject) {
+			fieldSchema, err := s.resolve(getOpenApiMap(schema, "properties")[fieldName])
+			if err != nil {
+				return err
+			}
+
+			if format := getOpenApiString(fieldSchema, "format"); mediaType == "multipart/form-data" && (format == "binary" || format == "base64") {
+				fields = append(fields, escape(fieldName)+"=@${"+getOpenApiVarName(fieldName)+"}")
+				continue
+			}
+
+			fields = append(fields, escape(fieldName+"="+formatOpenApiValue(exampleObject[fieldName])))
+		}
+
+		if mediaType == "multipart/form-data" {
+			t.multipart = fields
+		} else {
+			t.form = fields
+		}
+
+		return nil
+
+	case strings.Contains(mediaType, "json"):
+		t.body = formatJsonExample(example)
+
+	default:
+		t.body = escapeLines(strings.Split(formatOpenApiValue(example), "\n"))
+	}
+
+	t.headers = append(t.headers, "Content-Type: "+mediaType)
+
+	return nil
+}
+
+func isSkippedOpenApiHeader(name string) bool {
+	for _, skippedHeader := range skippedOpenApiHeaders {
+		if strings.EqualFold(name, skippedHeader) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s openApiSpec) getOpenApiTemplate(urlPath, method string, pathItem, operation map[string]interface{}) (template, error) {
+	t := template{}
+
+	parameters, err := s.getParameters(pathItem, operation)
+	if err != nil {
+		return t, err
+	}
+
+	pathVarNames := map[string]string{}
+	for _, parameter := range parameters {
+		switch {
+		case parameter.in == "path":
+			pathVarNames[parameter.name] = parameter.value
+
+		case parameter.in == "query":
+			queryLine := escape(parameter.name) + "=" + parameter.value
+			if !parameter.required {
+				queryLine = "# " + queryLine
+			}
+
+			t.query = append(t.query, queryLine)
+
+		case parameter.in == "header" && !isSkippedOpenApiHeader(parameter.name):
+			headerLine := escape(parameter.name) + ": " + parameter.value
+			if !parameter.required {
+				headerLine = "# " + headerLine
+			}
+
+			t.headers = append(t.headers, headerLine)
+		}
+	}
+
+	t.host = openApiPathParamRe.ReplaceAllStringFunc(escape(urlPath), func(pathParam string) string {
+		name := strings.Trim(pathParam, "{}")
+		if varName, exists := pathVarNames[name]; exists {
+			return varName
+		}
+
+		return "${" + getOpenApiVarName(name) + "}"
+	})
+
+	if method != "get" {
+		t.method = strings.ToUpper(method)
+	}
+
+	err = s.setOpenApiBody(&t, operation)
+
+	return t, err
+}
+
+// Server variables are set to their default value
+func getOpenApiHost(root map[string]interface{}) string {
+	servers, _ := root["servers"].([]interface{})
+	if len(servers) == 0 {
+		return defaultOpenApiHost
+	}
+
+	server, _ := servers[0].(map[string]interface{})
+	serverUrl := getOpenApiString(server, "url")
+	if serverUrl == "" || serverUrl == "/" {
+		return defaultOpenApiHost
+	}
+
+	serverVars := getOpenApiMap(server, "variables")
+	serverUrl = openApiPathParamRe.ReplaceAllStringFunc(serverUrl, func(serverVar string) string {
+		defaultValue := getOpenApiString(getOpenApiMap(serverVars, strings.Trim(serverVar, "{}")), "default")
+		if defaultValue == "" {
+			return serverVar
+		}
+
+		return defaultValue
+	})
+
+	// A relative url is relative to where the spec is served from
+	if strings.HasPrefix(serverUrl, "/") {
+		return defaultOpenApiHost + escape(strings.TrimSuffix(serverUrl, "/"))
+	}
+
+	return escape(strings.TrimSuffix(serverUrl, "/"))
+}
+
+// E g getUserById -> get-user-by-id.ain, falls back to the method and path
+func getOpenApiFilename(urlPath, method string, operation map[string]interface{}) string {
+	if operationId := getFilenameSlug(camelCaseRe.ReplaceAllString(getOpenApiString(operation, "operationId"), "${1}-${2}")); operationId != "" {
+		return operationId
+	}
+
+	return getFilenameSlug(method + " " + urlPath)
+}
+
+// GenerateOpenApiTemplates returns a template per operation in the OpenAPI 3
+// spec (JSON or YAML) and a base template holding the first server as [Host]
+// and the given backend sections. Operations are put in a folder per tag.
+func GenerateOpenApiTemplates(specBytes []byte, backendSections string) ([]data.ImportedFile, error) {
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(specBytes, &root); err != nil {
+		return nil, errors.Wrap(err, "could not parse OpenAPI spec")
+	}
+
+	if swaggerVersion, isSwagger := root["swagger"]; isSwagger {
+		return nil, errors.Errorf("only OpenAPI 3 specs are supported, got swagger %v", swaggerVersion)
+	}
+
+	if openApiVersion := fmt.Sprint(root["openapi"]); !strings.HasPrefix(openApiVersion, "3.") {
+		return nil, errors.New("not an OpenAPI 3 spec, missing openapi: 3.x")
+	}
+
+	spec := openApiSpec{root: normalizeYamlValue(root).(map[string]interface{})}
+
+	importedFiles := []data.ImportedFile{{
+		Filename: baseTemplateFilename,
+		Contents: "[Host]\n" + getOpenApiHost(spec.root) + "\n\n" + backendSections + "\n",
+	}}
+
+	paths := getOpenApiMap(spec.root, "paths")
+	takenNames := map[string]map[string]bool{}
+
+	for _, urlPath := range getSortedKeys(paths) {
+		pathItem, err := spec.resolve(paths[urlPath])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, method := range openApiMethods {
+			operation := getOpenApiMap(pathItem, method)
+			if operation == nil {
+				continue
+			}
+
+			t, err := spec.getOpenApiTemplate(urlPath, method, pathItem, operation)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not generate template for %s %s", strings.ToUpper(method), urlPath)
+			}
+
+			folder := ""
+			if tags, _ := operation["tags"].([]interface{}); len(tags) > 0 {
+				folder = getFilenameSlug(fmt.Sprint(tags[0]))
+			}
+
+			if takenNames[folder] == nil {
+				takenNames[folder] = map[string]bool{}
+			}
+
+			filename := getUniqueName(getOpenApiFilename(urlPath, method, operation), "operation", takenNames[folder]) + ".ain"
+
+			importedFiles = append(importedFiles, data.ImportedFile{
+				Filename: path.Join(folder, filename),
+				Contents: t.String(),
+			})
+		}
+	}
+
+	if len(importedFiles) == 1 {
+		return nil, errors.New("found no operations in OpenAPI spec")
+	}
+
+	return importedFiles, nil
+}
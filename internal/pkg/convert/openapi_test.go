@@ -0,0 +1,151 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_GenerateOpenApiTemplates(t *testing.T) {
+	specYaml := `
+openapi: 3.0.3
+servers:
+  - url: https://{env}.example.com/v1/
+    variables:
+      env: {default: api}
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetId'
+    get:
+      operationId: getPetById
+      tags: [pets]
+      parameters:
+        - {name: fields, in: query, required: true, example: "name,tags"}
+        - {name: expand, in: query, schema: {type: boolean, default: false}}
+        - {name: X-Request-Id, in: header, required: true, schema: {type: string}}
+        - {name: Accept, in: header, required: true, schema: {type: string}}
+    delete:
+      tags: [pets]
+  /pets:
+    post:
+      operationId: createPet
+      tags: [pets]
+      requestBody:
+        $ref: '#/components/requestBodies/Pet'
+  /upload:
+    post:
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              required: [photo]
+              properties:
+                caption: {type: string, example: "#cute"}
+                photo: {type: string, format: binary}
+                album: {type: string}
+  /notes:
+    put:
+      requestBody:
+        content:
+          text/plain:
+            examples:
+              short: {value: "${not a variable}"}
+components:
+  parameters:
+    PetId: {name: petId, in: path, required: true, schema: {type: integer}}
+  requestBodies:
+    Pet:
+      content:
+        application/xml:
+          example: <pet/>
+        application/json:
+          schema: {$ref: '#/components/schemas/Pet'}
+  schemas:
+    Pet:
+      allOf:
+        - properties:
+            name: {type: string, example: Rex}
+        - required: [tags, owner]
+          properties:
+            tags: {type: array, items: {type: string, enum: [cat, dog]}}
+            owner: {$ref: '#/components/schemas/Pet'}
+            nickname: {type: string}
+`
+
+	generatedFiles, err := GenerateOpenApiTemplates([]byte(specYaml), "[Backend]\ncurl")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedFiles := []data.ImportedFile{
+		{Filename: "_base.ain", Contents: "[Host]\nhttps://api.example.com/v1\n\n[Backend]\ncurl\n"},
+		{Filename: "put-notes.ain", Contents: "[Host]\n/notes\n\n[Headers]\nContent-Type: text/plain\n\n[Method]\nPUT\n\n[Body]\n`${not a variable}\n"},
+		{Filename: "pets/create-pet.ain", Contents: "[Host]\n/pets\n\n[Headers]\nContent-Type: application/json\n\n[Method]\nPOST\n\n[Body]\n{\n  \"name\": \"Rex\",\n  \"tags\": [\n    \"cat\"\n  ]\n}\n"},
+		{Filename: "pets/get-pet-by-id.ain", Contents: "[Host]\n/pets/${PET_ID}\n\n[Query]\nfields=name,tags\n# expand=false\n\n[Headers]\nX-Request-Id: ${X_REQUEST_ID}\n"},
+		{Filename: "pets/delete-pets-petid.ain", Contents: "[Host]\n/pets/${PET_ID}\n\n[Method]\nDELETE\n"},
+		{Filename: "post-upload.ain", Contents: "[Host]\n/upload\n\n[Method]\nPOST\n\n[Multipart]\ncaption=`#cute\nphoto=@${PHOTO}\n"},
+	}
+
+	if !reflect.DeepEqual(generatedFiles, expectedFiles) {
+		t.Errorf("Unexpected files: %+v", generatedFiles)
+	}
+}
+
+func Test_GenerateOpenApiTemplatesNoServers(t *testing.T) {
+	specJson := `{"openapi": "3.1.0", "paths": {"/": {"get": {}}}}`
+
+	generatedFiles, err := GenerateOpenApiTemplates([]byte(specJson), "[Backend]\nnative")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedFiles := []data.ImportedFile{
+		{Filename: "_base.ain", Contents: "[Host]\nhttp://localhost:${PORT}\n\n[Backend]\nnative\n"},
+		{Filename: "get.ain", Contents: "[Host]\n/\n"},
+	}
+
+	if !reflect.DeepEqual(generatedFiles, expectedFiles) {
+		t.Errorf("Unexpected files: %+v", generatedFiles)
+	}
+}
+
+func Test_GenerateOpenApiTemplatesBadCases(t *testing.T) {
+	tests := map[string]struct {
+		spec          string
+		expectedError string
+	}{
+		"Not YAML": {
+			spec:          "openapi: [",
+			expectedError: "could not parse OpenAPI spec: yaml: line 1: did not find expected node content",
+		},
+		"Swagger": {
+			spec:          "swagger: '2.0'",
+			expectedError: "only OpenAPI 3 specs are supported, got swagger 2.0",
+		},
+		"Not OpenAPI": {
+			spec:          "name: ain",
+			expectedError: "not an OpenAPI 3 spec, missing openapi: 3.x",
+		},
+		"No operations": {
+			spec:          "openapi: 3.0.0\npaths: {}",
+			expectedError: "found no operations in OpenAPI spec",
+		},
+		"External ref": {
+			spec:          "openapi: 3.0.0\npaths:\n  /:\n    post:\n      requestBody: {$ref: 'bodies.yaml#/Pet'}",
+			expectedError: "could not generate template for POST /: cannot resolve $ref bodies.yaml#/Pet, only refs within the spec are supported",
+		},
+		"Missing ref": {
+			spec:          "openapi: 3.0.0\npaths:\n  /:\n    get:\n      parameters: [{$ref: '#/components/parameters/Id'}]",
+			expectedError: "could not generate template for GET /: cannot resolve $ref #/components/parameters/Id, found nothing there",
+		},
+	}
+
+	for name, test := range tests {
+		_, err := GenerateOpenApiTemplates([]byte(test.spec), "[Backend]\ncurl")
+		if err == nil || err.Error() != test.expectedError {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+		}
+	}
+}
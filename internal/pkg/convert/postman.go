@@ -0,0 +1,466 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/pkg/errors"
+)
+
+type postmanKeyValue struct {
+	Key         string          `json:"key"`
+	Value       json.RawMessage `json:"value"`
+	Disabled    bool            `json:"disabled"`
+	Type        string          `json:"type"`
+	Src         json.RawMessage `json:"src"`
+	ContentType string          `json:"contentType"`
+}
+
+type postmanAuth struct {
+	Type   string                       `json:"type"`
+	Params map[string][]postmanKeyValue `json:"-"`
+}
+
+type postmanEvent struct {
+	Listen   string `json:"listen"`
+	Disabled bool   `json:"disabled"`
+	Script   struct {
+		Exec json.RawMessage `json:"exec"`
+	} `json:"script"`
+}
+
+type postmanUrl struct {
+	Raw      string            `json:"raw"`
+	Query    []postmanKeyValue `json:"query"`
+	Variable []postmanKeyValue `json:"variable"`
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Disabled   bool              `json:"disabled"`
+	Raw        string            `json:"raw"`
+	Urlencoded []postmanKeyValue `json:"urlencoded"`
+	Formdata   []postmanKeyValue `json:"formdata"`
+	File       struct {
+		Src string `json:"src"`
+	} `json:"file"`
+	GraphQL struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Url    postmanUrl        `json:"url"`
+	Header []postmanKeyValue `json:"header"`
+	Body   *postmanBody      `json:"body"`
+	Auth   *postmanAuth      `json:"auth"`
+}
+
+// A folder has items, a request has a request
+type postmanItem struct {
+	Name     string            `json:"name"`
+	Item     []postmanItem     `json:"item"`
+	Request  *postmanRequest   `json:"request"`
+	Auth     *postmanAuth      `json:"auth"`
+	Event    []postmanEvent    `json:"event"`
+	Variable []postmanKeyValue `json:"variable"`
+}
+
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	postmanItem
+}
+
+// {{var}}, and {{$guid}} for the built in dynamic variables
+var postmanPlaceholderRe = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+// Set by Postman for raw bodies unless there's a Content-Type header
+var postmanRawContentTypes = map[string]string{
+	"json":       "application/json",
+	"xml":        "application/xml",
+	"html":       "text/html",
+	"javascript": "application/javascript",
+	"text":       "text/plain",
+}
+
+// The auth parameters are in a list named after the type, e g "basic": [...]
+func (a *postmanAuth) UnmarshalJSON(authBytes []byte) error {
+	authFields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(authBytes, &authFields); err != nil {
+		return err
+	}
+
+	if typeBytes, exists := authFields["type"]; exists {
+		if err := json.Unmarshal(typeBytes, &a.Type); err != nil {
+			return err
+		}
+	}
+
+	a.Params = map[string][]postmanKeyValue{}
+
+	// Collections before v2.1 have an object instead of a list
+	params := []postmanKeyValue{}
+	if err := json.Unmarshal(authFields[a.Type], &params); err == nil {
+		a.Params[a.Type] = params
+	}
+
+	return nil
+}
+
+func (a *postmanAuth) getParam(key string) string {
+	for _, param := range a.Params[a.Type] {
+		if param.Key == key {
+			return param.getValue()
+		}
+	}
+
+	return ""
+}
+
+// A url can be given as a plain string
+func (u *postmanUrl) UnmarshalJSON(urlBytes []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(urlBytes), []byte(`"`)) {
+		return json.Unmarshal(urlBytes, &u.Raw)
+	}
+
+	type plainPostmanUrl postmanUrl
+	return json.Unmarshal(urlBytes, (*plainPostmanUrl)(u))
+}
+
+// Values are mostly strings, but can be numbers or booleans
+func (kv postmanKeyValue) getValue() string {
+	value := ""
+	if err := json.Unmarshal(kv.Value, &value); err == nil {
+		return value
+	}
+
+	if string(kv.Value) == "null" {
+		return ""
+	}
+
+	return string(kv.Value)
+}
+
+func getPostmanVarName(placeholder string) (string, string) {
+	name := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(placeholder, "{{"), "}}"))
+
+	if strings.HasPrefix(name, "$") {
+		return "", "the dynamic variable " + placeholder
+	}
+
+	if name == "" {
+		return "", "the empty variable " + placeholder
+	}
+
+	return name, ""
+}
+
+func translatePostmanVars(text string, notes *importNotes) string {
+	return translateVars(text, postmanPlaceholderRe, getPostmanVarName, notes)
+}
+
+func getPostmanScriptNotes(events []postmanEvent, notes *importNotes) {
+	for _, event := range events {
+		exec := []string{}
+		if err := json.Unmarshal(event.Script.Exec, &exec); err != nil {
+			execLine := ""
+			_ = json.Unmarshal(event.Script.Exec, &execLine)
+			exec = []string{execLine}
+		}
+
+		if event.Disabled || strings.TrimSpace(strings.Join(exec, "")) == "" {
+			continue
+		}
+
+		switch event.Listen {
+		case "prerequest":
+			notes.add("pre-request script")
+		case "test":
+			notes.add("test script")
+		default:
+			notes.add(event.Listen + " script")
+		}
+	}
+}
+
+func getPostmanVars(variables []postmanKeyValue, notes *importNotes) [][]string {
+	vars := [][]string{}
+
+	for _, variable := range variables {
+		if variable.Disabled || variable.Key == "" {
+			continue
+		}
+
+		value := variable.getValue()
+		if postmanPlaceholderRe.MatchString(value) {
+			notes.add("the variables used in the value of " + variable.Key)
+		}
+
+		vars = append(vars, []string{getVarName(variable.Key), value})
+	}
+
+	return vars
+}
+
+// Returns the [Auth] line, empty if it can't be imported
+func getPostmanAuth(auth *postmanAuth, notes *importNotes) string {
+	switch auth.Type {
+	case "basic", "digest":
+		return translatePostmanVars(auth.Type+" "+auth.getParam("username")+":"+auth.getParam("password"), notes)
+
+	case "bearer":
+		return translatePostmanVars("bearer "+auth.getParam("token"), notes)
+
+	case "apikey":
+		in := auth.getParam("in")
+		if in == "" {
+			in = "header"
+		}
+
+		return translatePostmanVars("apikey "+in+" "+auth.getParam("key")+" "+auth.getParam("value"), notes)
+	}
+
+	notes.add(auth.Type + " auth")
+	return ""
+}
+
+// Disabled lines are kept as comments
+func getPostmanKeyValueLines(keyValues []postmanKeyValue, separator string, notes *importNotes) []string {
+	lines := []string{}
+
+	for _, keyValue := range keyValues {
+		line := translatePostmanVars(keyValue.Key, notes)
+
+		// A query key without a value is sent as ?key
+		if len(keyValue.Value) > 0 && string(keyValue.Value) != "null" {
+			line += separator + translatePostmanVars(keyValue.getValue(), notes)
+		}
+
+		if keyValue.Disabled {
+			line = "# " + line
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func getPostmanMultipart(formdata []postmanKeyValue, notes *importNotes) []string {
+	multipart := []string{}
+
+	for _, field := range formdata {
+		if field.Type != "file" {
+			multipart = append(multipart, getPostmanKeyValueLines([]postmanKeyValue{field}, "=", notes)...)
+			continue
+		}
+
+		srcs := []string{}
+		if err := json.Unmarshal(field.Src, &srcs); err != nil {
+			src := ""
+			_ = json.Unmarshal(field.Src, &src)
+			srcs = []string{src}
+		}
+
+		if len(srcs) != 1 || srcs[0] == "" {
+			notes.add("the files of the form field " + field.Key)
+			continue
+		}
+
+		line := escape(field.Key + "=@" + srcs[0])
+		if field.ContentType != "" {
+			line += ";type=" + escape(field.ContentType)
+		}
+
+		if field.Disabled {
+			line = "# " + line
+		}
+
+		multipart = append(multipart, line)
+	}
+
+	return multipart
+}
+
+func setPostmanBody(t *template, body *postmanBody, notes *importNotes) {
+	switch body.Mode {
+	case "raw":
+		if body.Raw == "" {
+			return
+		}
+
+		for _, line := range strings.Split(body.Raw, "\n") {
+			t.body = append(t.body, translatePostmanVars(line, notes))
+		}
+
+		language := body.Options.Raw.Language
+		if language == "" {
+			language = "text"
+		}
+
+		if contentType, exists := postmanRawContentTypes[language]; exists && !hasHeader(t.headers, "Content-Type") {
+			t.headers = append(t.headers, "Content-Type: "+contentType)
+		}
+
+	case "urlencoded":
+		t.form = getPostmanKeyValueLines(body.Urlencoded, "=", notes)
+
+	case "formdata":
+		t.multipart = getPostmanMultipart(body.Formdata, notes)
+
+	case "file":
+		if body.File.Src == "" {
+			notes.add("the file body")
+			return
+		}
+
+		t.bodyFile = escape(body.File.Src)
+
+	case "graphql":
+		for _, line := range strings.Split(body.GraphQL.Query, "\n") {
+			t.graphQL = append(t.graphQL, translatePostmanVars(line, notes))
+		}
+
+		if strings.TrimSpace(body.GraphQL.Variables) != "" {
+			for _, line := range strings.Split(body.GraphQL.Variables, "\n") {
+				t.graphQLVars = append(t.graphQLVars, translatePostmanVars(line, notes))
+			}
+		}
+
+	case "":
+
+	default:
+		notes.add("the " + body.Mode + " body")
+	}
+}
+
+// Path variables such as /users/:id are set in the request
+func getPostmanHost(requestUrl postmanUrl) string {
+	host, _, _ := strings.Cut(requestUrl.Raw, "?")
+	host, _, _ = strings.Cut(host, "#")
+
+	pathSegments := strings.Split(host, "/")
+
+	for _, variable := range requestUrl.Variable {
+		value := variable.getValue()
+		if value == "" {
+			value = "{{" + variable.Key + "}}"
+		}
+
+		for i, pathSegment := range pathSegments {
+			if pathSegment == ":"+variable.Key {
+				pathSegments[i] = value
+			}
+		}
+	}
+
+	return strings.Join(pathSegments, "/")
+}
+
+func getPostmanTemplate(request *postmanRequest, inheritedAuth string, notes *importNotes) template {
+	t := template{
+		host:    translatePostmanVars(getPostmanHost(request.Url), notes),
+		headers: getPostmanKeyValueLines(request.Header, ": ", notes),
+	}
+
+	if len(request.Url.Query) > 0 {
+		t.query = getPostmanKeyValueLines(request.Url.Query, "=", notes)
+	} else if _, rawQuery, found := strings.Cut(request.Url.Raw, "?"); found && rawQuery != "" {
+		rawQuery, _, _ = strings.Cut(rawQuery, "#")
+		for _, queryLine := range strings.Split(rawQuery, "&") {
+			t.query = append(t.query, translatePostmanVars(queryLine, notes))
+		}
+	}
+
+	if method := strings.ToUpper(request.Method); method != "" && method != "GET" {
+		t.method = method
+	}
+
+	if request.Body != nil && !request.Body.Disabled {
+		setPostmanBody(&t, request.Body, notes)
+	}
+
+	setPostmanAuth(&t, request.Auth, inheritedAuth, notes)
+
+	return t
+}
+
+// No auth removes any inherited auth and a missing auth inherits it
+func setPostmanAuth(t *template, auth *postmanAuth, inheritedAuth string, notes *importNotes) {
+	switch {
+	case auth == nil || auth.Type == "inherit":
+
+	case auth.Type == "noauth":
+		if inheritedAuth != "" {
+			removedHeaders, removedQuery := getAuthRemovals(inheritedAuth)
+			t.headers = append(t.headers, removedHeaders...)
+			t.query = append(t.query, removedQuery...)
+		}
+
+	default:
+		t.auth = getPostmanAuth(auth, notes)
+	}
+}
+
+func getPostmanFolder(item postmanItem, inheritedAuth string) *collectionFolder {
+	folder := &collectionFolder{name: item.Name}
+
+	folder.vars = getPostmanVars(item.Variable, &folder.notImported)
+	getPostmanScriptNotes(item.Event, &folder.notImported)
+
+	setPostmanAuth(&folder.baseTemplate, item.Auth, inheritedAuth, &folder.notImported)
+	if item.Auth != nil && item.Auth.Type == "noauth" {
+		inheritedAuth = ""
+	} else if folder.baseTemplate.auth != "" {
+		inheritedAuth = folder.baseTemplate.auth
+	}
+
+	for _, childItem := range item.Item {
+		if childItem.Request == nil {
+			folder.folders = append(folder.folders, getPostmanFolder(childItem, inheritedAuth))
+			continue
+		}
+
+		request := collectionRequest{name: childItem.Name}
+		request.template = getPostmanTemplate(childItem.Request, inheritedAuth, &request.notImported)
+		getPostmanScriptNotes(childItem.Event, &request.notImported)
+
+		folder.requests = append(folder.requests, request)
+	}
+
+	return folder
+}
+
+// ImportPostman returns the templates of a Postman v2.1 collection laid
+// out as its folders, together with what could not be imported.
+func ImportPostman(collectionBytes []byte) ([]data.ImportedFile, []data.NotImported, error) {
+	collection := postmanCollection{}
+	if err := json.Unmarshal(collectionBytes, &collection); err != nil {
+		return nil, nil, errors.Wrap(err, "could not parse Postman collection")
+	}
+
+	if !strings.Contains(collection.Info.Schema, "schema.getpostman.com") {
+		return nil, nil, errors.New("not a Postman collection, missing the schema in info")
+	}
+
+	if !strings.Contains(collection.Info.Schema, "/v2.1") {
+		return nil, nil, errors.Errorf("only Postman collections v2.1 can be imported, got %s", collection.Info.Schema)
+	}
+
+	importedFiles, notImported := getPostmanFolder(collection.postmanItem, "").getImportedFiles()
+
+	return importedFiles, notImported, nil
+}
@@ -0,0 +1,93 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_ImportPostman(t *testing.T) {
+	collectionJson := `{
+		"info": {"name": "Users API", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}"}]},
+		"variable": [{"key": "baseUrl", "value": "https://api.example.com"}, {"key": "token", "value": "secret"}],
+		"event": [{"listen": "prerequest", "script": {"exec": ["pm.environment.set('x', 1)"]}}],
+		"item": [
+			{"name": "Users", "variable": [{"key": "user-id", "value": 42}], "item": [
+				{"name": "Get user", "request": {"method": "GET",
+					"header": [{"key": "Accept", "value": "application/json"}, {"key": "X-Debug", "value": "1", "disabled": true}],
+					"url": {"raw": "{{baseUrl}}/users/:id?expand", "query": [{"key": "expand", "value": null}], "variable": [{"key": "id", "value": "{{user-id}}"}]}},
+					"event": [{"listen": "test", "script": {"exec": ["pm.test('ok', () => {})"]}}]},
+				{"name": "Create user", "request": {"method": "post", "url": "{{baseUrl}}/users",
+					"body": {"mode": "raw", "raw": "{\n  \"id\": \"{{$guid}}\",\n  \"tag\": \"#1\"\n}", "options": {"raw": {"language": "json"}}}}},
+				{"name": "Create user", "request": {"method": "POST", "url": "{{baseUrl}}/users",
+					"body": {"mode": "formdata", "formdata": [{"key": "name", "value": "ain", "type": "text"}, {"key": "avatar", "type": "file", "src": "avatar.png"}]}}}
+			]},
+			{"name": "Health", "request": {"method": "GET", "auth": {"type": "noauth"}, "url": {"raw": "{{baseUrl}}/health"}}},
+			{"name": "Login", "request": {"method": "POST", "auth": {"type": "apikey", "apikey": [{"key": "key", "value": "X-Api-Key"}, {"key": "value", "value": "{{apiKey}}"}]},
+				"body": {"mode": "urlencoded", "urlencoded": [{"key": "user", "value": "ain"}]}, "url": "{{baseUrl}}/login?from=ain"}},
+			{"name": "Search", "request": {"method": "POST", "auth": {"type": "oauth2", "oauth2": []}, "url": "{{baseUrl}}/graphql",
+				"body": {"mode": "graphql", "graphql": {"query": "query { users { id } }", "variables": "{\"first\": 1}"}}}}
+		]
+	}`
+
+	importedFiles, notImported, err := ImportPostman([]byte(collectionJson))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedFiles := []data.ImportedFile{
+		{Filename: "_base.ain", Contents: "[Auth]\nbearer ${token}\n\n[Backend]\ncurl\n"},
+		{Filename: ".env", Contents: "baseUrl=\"https://api.example.com\"\ntoken=\"secret\"\n"},
+		{Filename: "health.ain", Contents: "[Host]\n${baseUrl}/health\n\n[Headers]\n-Authorization\n"},
+		{Filename: "login.ain", Contents: "[Host]\n${baseUrl}/login\n\n[Query]\nfrom=ain\n\n[Method]\nPOST\n\n[Auth]\napikey header X-Api-Key ${apiKey}\n\n[Form]\nuser=ain\n"},
+		{Filename: "search.ain", Contents: "[Host]\n${baseUrl}/graphql\n\n[Method]\nPOST\n\n[GraphQL]\nquery { users { id } }\n\n[GraphQLVariables]\n{\"first\": 1}\n"},
+		{Filename: "users/.env", Contents: "baseUrl=\"https://api.example.com\"\ntoken=\"secret\"\nuser_id=\"42\"\n"},
+		{Filename: "users/get-user.ain", Contents: "[Host]\n${baseUrl}/users/${user_id}\n\n[Query]\nexpand\n\n[Headers]\nAccept: application/json\n# X-Debug: 1\n"},
+		{Filename: "users/create-user.ain", Contents: "[Host]\n${baseUrl}/users\n\n[Headers]\nContent-Type: application/json\n\n[Method]\nPOST\n\n[Body]\n{\n  \"id\": \"{{$guid}}\",\n  \"tag\": \"`#1\"\n}\n"},
+		{Filename: "users/create-user-2.ain", Contents: "[Host]\n${baseUrl}/users\n\n[Method]\nPOST\n\n[Multipart]\nname=ain\navatar=@avatar.png\n"},
+	}
+
+	if !reflect.DeepEqual(importedFiles, expectedFiles) {
+		t.Errorf("Unexpected files: %+v", importedFiles)
+	}
+
+	expectedNotImported := []data.NotImported{
+		{Filename: "", What: "pre-request script"},
+		{Filename: "search.ain", What: "oauth2 auth"},
+		{Filename: "users/get-user.ain", What: "test script"},
+		{Filename: "users/create-user.ain", What: "the dynamic variable {{$guid}}"},
+	}
+
+	if !reflect.DeepEqual(notImported, expectedNotImported) {
+		t.Errorf("Unexpected not imported: %+v", notImported)
+	}
+}
+
+func Test_ImportPostmanBadCases(t *testing.T) {
+	tests := map[string]struct {
+		collectionJson string
+		expectedError  string
+	}{
+		"Not JSON": {
+			collectionJson: "<html>",
+			expectedError:  "could not parse Postman collection: invalid character '<' looking for beginning of value",
+		},
+		"Not a collection": {
+			collectionJson: `{"log": {}}`,
+			expectedError:  "not a Postman collection, missing the schema in info",
+		},
+		"Old version": {
+			collectionJson: `{"info": {"schema": "https://schema.getpostman.com/json/collection/v2.0.0/collection.json"}}`,
+			expectedError:  "only Postman collections v2.1 can be imported, got https://schema.getpostman.com/json/collection/v2.0.0/collection.json",
+		},
+	}
+
+	for name, test := range tests {
+		_, _, err := ImportPostman([]byte(test.collectionJson))
+		if err == nil || err.Error() != test.expectedError {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+		}
+	}
+}
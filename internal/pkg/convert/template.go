@@ -0,0 +1,74 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/parse"
+)
+
+// The sections of an imported request. Lines are written
+// as is, use escape on anything that is not meant as a
+// variable, executable or comment in the template.
+type template struct {
+	host           string
+	query          []string
+	headers        []string
+	method         string
+	auth           string
+	body           []string
+	bodyFile       string
+	form           []string
+	multipart      []string
+	graphQL        []string
+	graphQLVars    []string
+	backend        string
+	backendOptions []string
+}
+
+func escape(text string) string {
+	return parse.EscapeTemplateText(text)
+}
+
+func escapeLines(lines []string) []string {
+	escapedLines := []string{}
+	for _, line := range lines {
+		escapedLines = append(escapedLines, escape(line))
+	}
+
+	return escapedLines
+}
+
+func writeSection(builder *strings.Builder, heading string, lines ...string) {
+	if len(lines) == 0 || len(lines) == 1 && lines[0] == "" {
+		return
+	}
+
+	if builder.Len() > 0 {
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(heading + "\n")
+	for _, line := range lines {
+		builder.WriteString(line + "\n")
+	}
+}
+
+func (t template) String() string {
+	var builder strings.Builder
+
+	writeSection(&builder, "[Host]", t.host)
+	writeSection(&builder, "[Query]", t.query...)
+	writeSection(&builder, "[Headers]", t.headers...)
+	writeSection(&builder, "[Method]", t.method)
+	writeSection(&builder, "[Auth]", t.auth)
+	writeSection(&builder, "[Body]", t.body...)
+	writeSection(&builder, "[BodyFile]", t.bodyFile)
+	writeSection(&builder, "[Form]", t.form...)
+	writeSection(&builder, "[Multipart]", t.multipart...)
+	writeSection(&builder, "[GraphQL]", t.graphQL...)
+	writeSection(&builder, "[GraphQLVariables]", t.graphQLVars...)
+	writeSection(&builder, "[Backend]", t.backend)
+	writeSection(&builder, "[BackendOptions]", t.backendOptions...)
+
+	return builder.String()
+}
@@ -1,14 +1,19 @@
 package data
 
 import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/textproto"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
 )
 
 func (bi *BackendInput) CreateBodyTempFile() error {
-	if len(bi.Body) == 0 {
+	if len(bi.Body) == 0 && bi.BodyFileName == "" {
 		return nil
 	}
 
@@ -23,14 +28,24 @@ func (bi *BackendInput) CreateBodyTempFile() error {
 		tempFileDir = cwd
 	}
 
-	bodyStr := strings.Join(bi.Body, "\n")
+	var body io.Reader = strings.NewReader(strings.Join(bi.Body, "\n"))
+
+	if bi.BodyFileName != "" {
+		bodyFile, err := os.Open(bi.BodyFileName)
+		if err != nil {
+			return errors.Wrapf(err, "could not open [BodyFile] %s", bi.BodyFileName)
+		}
+
+		defer bodyFile.Close()
+		body = bodyFile
+	}
 
 	tmpFile, err := os.CreateTemp(tempFileDir, "ain-body")
 	if err != nil {
 		return errors.Wrap(err, "could not create tempfile")
 	}
 
-	if _, err := tmpFile.Write([]byte(bodyStr)); err != nil {
+	if _, err := io.Copy(tmpFile, body); err != nil {
 		// This also returns an error, but the first is more significant
 		// so ignore this, it's only a temp-file that will be deleted eventually
 		_ = tmpFile.Close()
@@ -43,6 +58,73 @@ func (bi *BackendInput) CreateBodyTempFile() error {
 	return nil
 }
 
+func writeMultipartFile(writer *multipart.Writer, field MultipartField) error {
+	contentType := field.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", `form-data; name="`+escapeQuotes(field.Name)+`"; filename="`+escapeQuotes(filepath.Base(field.Filename))+`"`)
+	partHeader.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(partHeader)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(field.Filename)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(part, file)
+	return err
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// SetMultipartBody replaces the [Multipart] fields with a generated
+// body, for backends without flags of their own for sending forms
+func (bi *BackendInput) SetMultipartBody() error {
+	if len(bi.Multipart) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, field := range bi.Multipart {
+		if field.Filename == "" {
+			if err := writer.WriteField(field.Name, field.Value); err != nil {
+				return errors.Wrapf(err, "could not write [Multipart] field %s", field.Name)
+			}
+
+			continue
+		}
+
+		if err := writeMultipartFile(writer, field); err != nil {
+			return errors.Wrapf(err, "could not write [Multipart] file %s", field.Filename)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return errors.Wrap(err, "could not write [Multipart] body")
+	}
+
+	// A single line so the body is written back as is
+	bi.Body = []string{body.String()}
+	bi.Headers = append(bi.Headers, "Content-Type: "+writer.FormDataContentType())
+
+	return nil
+}
+
 func (bi *BackendInput) RemoveBodyTempFile(forceDeletion bool) error {
 	if bi.TempFileName == "" {
 		return nil
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_SetMultipartBody(t *testing.T) {
+	avatarFilename := filepath.Join(t.TempDir(), "avatar.png")
+	avatarContents := "\x89PNG\r\n\x1a\n\x00"
+	if err := os.WriteFile(avatarFilename, []byte(avatarContents), 0644); err != nil {
+		t.Fatalf("Could not write test file: %v", err)
+	}
+
+	backendInput := BackendInput{Multipart: []MultipartField{
+		{Name: "name", Value: "ain"},
+		{Name: "avatar", Filename: avatarFilename, ContentType: "image/png"},
+	}}
+
+	if err := backendInput.SetMultipartBody(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(backendInput.Headers) != 1 || len(backendInput.Body) != 1 {
+		t.Fatalf("Expected one header and one body line, got: %v %d", backendInput.Headers, len(backendInput.Body))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(strings.TrimPrefix(backendInput.Headers[0], "Content-Type: "))
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("Unexpected Content-Type: %s", backendInput.Headers[0])
+	}
+
+	reader := multipart.NewReader(strings.NewReader(backendInput.Body[0]), params["boundary"])
+
+	expectedParts := []struct{ name, filename, contentType, contents string }{
+		{"name", "", "", "ain"},
+		{"avatar", "avatar.png", "image/png", avatarContents},
+	}
+
+	for _, expectedPart := range expectedParts {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Could not read part %s: %v", expectedPart.name, err)
+		}
+
+		contents, _ := io.ReadAll(part)
+
+		if part.FormName() != expectedPart.name ||
+			part.FileName() != expectedPart.filename ||
+			part.Header.Get("Content-Type") != expectedPart.contentType ||
+			string(contents) != expectedPart.contents {
+			t.Errorf("Unexpected part: %s %s %s %q", part.FormName(), part.FileName(), part.Header.Get("Content-Type"), contents)
+		}
+	}
+
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("Expected no more parts, got: %v", err)
+	}
+}
+
+func Test_CreateBodyTempFileFromBodyFile(t *testing.T) {
+	bodyFilename := filepath.Join(t.TempDir(), "message.pb")
+	bodyContents := "\x08\x96\x01\r\n\x00"
+	if err := os.WriteFile(bodyFilename, []byte(bodyContents), 0644); err != nil {
+		t.Fatalf("Could not write test file: %v", err)
+	}
+
+	backendInput := BackendInput{BodyFileName: bodyFilename}
+	if err := backendInput.CreateBodyTempFile(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	defer backendInput.RemoveBodyTempFile(true)
+
+	tempFileContents, err := os.ReadFile(backendInput.TempFileName)
+	if err != nil || string(tempFileContents) != bodyContents {
+		t.Errorf("Expected the body file byte-for-byte, got: %q %v", tempFileContents, err)
+	}
+}
@@ -1,25 +1,42 @@
 package data
 
 import (
+	"net/http"
 	"net/url"
+	"time"
 )
 
 const TimeoutNotSet = -1
+const ExecCacheNotSet = -1
+const BodyMergeNotSet = ""
+
+const (
+	BodyMergeJson = "json"
+	BodyMergeNone = "none"
+)
 
 type Config struct {
 	Timeout    int32
 	QueryDelim *string
+	// How long executable output is reused, 0 disables the cache
+	ExecCache time.Duration
+	// How [Body] sections combine across templates, overwritten if not set
+	BodyMerge string
 }
 
 func NewConfig() Config {
-	return Config{Timeout: TimeoutNotSet}
+	return Config{Timeout: TimeoutNotSet, ExecCache: ExecCacheNotSet}
 }
 
 type BackendInput struct {
-	Host    *url.URL
-	Body    []string
-	Method  string
-	Headers []string
+	Host *url.URL
+	Body []string
+	// Sent byte-for-byte instead of Body if set
+	BodyFileName string
+	// Sent as the backends own form flags, or a generated body
+	Multipart []MultipartField
+	Method    string
+	Headers   []string
 
 	Backend        string
 	BackendOptions [][]string
@@ -28,12 +45,170 @@ type BackendInput struct {
 	LeaveTempFile bool
 
 	TempFileName string
+
+	Assertions []Assertion
+	Captures   []Capture
+
+	// Nil if the template has no [Auth]
+	Auth *Auth
+
+	// Likely mistakes in the templates, printed but not fatal
+	Warnings []string
+}
+
+// The backend needs to report the response if anything
+// is to be checked against or captured from it
+func (bi *BackendInput) CaptureResponse() bool {
+	return len(bi.Assertions) > 0 || len(bi.Captures) > 0
+}
+
+type MultipartField struct {
+	Name  string
+	Value string
+	// Set instead of Value for a file
+	Filename string
+	// Optional for a file
+	ContentType string
+}
+
+const (
+	StatusSubject = "status"
+	HeaderSubject = "header"
+	BodySubject   = "body"
+)
+
+type ResponseSelector struct {
+	Subject string
+	// Header name for HeaderSubject, optional JSON path for BodySubject
+	Name string
+}
+
+type Assertion struct {
+	Selector ResponseSelector
+	Operator string
+	Expected string
+
+	Filename string
+	// Formats msg with the line and template context of the assertion
+	FormatFatal func(msg string) string
+}
+
+const (
+	BasicAuthScheme  = "basic"
+	BearerAuthScheme = "bearer"
+	DigestAuthScheme = "digest"
+	// Resolved to a bearer token before the backend is called
+	OAuth2AuthScheme = "oauth2"
+	// Signed when the body is known, sent as headers
+	AwsSigV4AuthScheme = "aws-sigv4"
+)
+
+const (
+	ClientCredentialsGrant = "client_credentials"
+	RefreshTokenGrant      = "refresh_token"
+)
+
+type OAuth2 struct {
+	GrantType    string
+	TokenUrl     string
+	ClientId     string
+	ClientSecret string
+	Scope        string
+	RefreshToken string
+	// Send the client id and secret as form values instead of basic auth
+	ClientAuthInBody bool
+}
+
+type OAuth2Token struct {
+	AccessToken string
+	// Empty unless the token endpoint returned one
+	RefreshToken string
+	// Zero if the token endpoint did not say
+	ExpiresIn time.Duration
+}
+
+type AwsSigV4 struct {
+	Region          string
+	Service         string
+	AccessKeyId     string
+	SecretAccessKey string
+	// Only set for temporary credentials
+	SessionToken string
+}
+
+// Auth is turned into the backends own auth flags
+type Auth struct {
+	Scheme string
+	// Set for basic and digest
+	User     string
+	Password string
+	// Set for bearer
+	Token string
+	// Set for oauth2
+	OAuth2 *OAuth2
+	// Set for aws-sigv4
+	AwsSigV4 *AwsSigV4
 }
 
 type TimeoutContextValueKey struct{}
 
+// Set when executables should not use the cache
+type NoExecCacheContextValueKey struct{}
+
+// Set when the request is printed or exported instead of sent
+type PrintOnlyContextValueKey struct{}
+
+type Capture struct {
+	VarName  string
+	Selector ResponseSelector
+
+	Filename string
+	// Formats msg with the line and template context of the capture
+	FormatFatal func(msg string) string
+}
+
+type Response struct {
+	// Zero and nil if the backend cannot report them
+	StatusCode int
+	Headers    http.Header
+	Body       string
+	// Status line and headers as written by the backend. Left
+	// out of the Body if also on stdout, e g with curl -i
+	Head string
+}
+
 type BackendOutput struct {
 	Stderr   string
 	Stdout   string
 	ExitCode int
+
+	// Only set if BackendInput.CaptureResponse()
+	Response *Response
+}
+
+// A template or .env-file created by an import
+type ImportedFile struct {
+	// Relative to the folder imported to
+	Filename string
+	Contents string
+}
+
+// Something in an imported collection ain has no counterpart for
+type NotImported struct {
+	// The template or folder, relative to the folder imported to
+	Filename string
+	What     string
+}
+
+// An assembled request, named as it's run: users/api.ain#get-user
+type ExportedRequest struct {
+	Name         string
+	BackendInput *BackendInput
+}
+
+// Something in an assembled request the export format has no counterpart for
+type NotExported struct {
+	// The request, named as it's run
+	Name string
+	What string
 }
@@ -0,0 +1,98 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Picked up from the folder of a template and all folders above it
+var baseTemplateFileNames = []string{"_base.ain", filepath.Join(".ain", "base.ain")}
+
+// A folder with any of these is the root of a workspace
+var workspaceRootMarkers = []string{".git", ".hg", ".svn"}
+
+func isWorkspaceRoot(dir string) bool {
+	for _, workspaceRootMarker := range workspaceRootMarkers {
+		if _, err := os.Stat(filepath.Join(dir, workspaceRootMarker)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the folder of the template and the ones above it, up to
+// the workspace root, the home folder or the root of the file system
+func getBaseTemplateDirs(templateFileName string) ([]string, error) {
+	dir, err := filepath.Abs(filepath.Dir(templateFileName))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot find the folder of template file "+templateFileName)
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	dirs := []string{}
+
+	for {
+		dirs = append(dirs, dir)
+
+		parentDir := filepath.Dir(dir)
+		if isWorkspaceRoot(dir) || dir == homeDir || parentDir == dir {
+			break
+		}
+
+		dir = parentDir
+	}
+
+	return dirs, nil
+}
+
+// GetBaseTemplateFilenames finds the base templates (_base.ain or
+// .ain/base.ain) for the template files. The ones furthest up
+// come first, templates already given are not returned.
+func GetBaseTemplateFilenames(templateFileNames []string) ([]string, error) {
+	baseTemplateFilenames := []string{}
+	seenTemplateFileNames := map[string]bool{}
+
+	for _, templateFileName := range templateFileNames {
+		absTemplateFileName, err := filepath.Abs(strings.TrimSuffix(templateFileName, "!"))
+		if err == nil {
+			seenTemplateFileNames[absTemplateFileName] = true
+		}
+	}
+
+	workingDir, _ := os.Getwd()
+
+	for _, templateFileName := range templateFileNames {
+		dirs, err := getBaseTemplateDirs(templateFileName)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := len(dirs) - 1; i >= 0; i-- {
+			for _, baseTemplateFileName := range baseTemplateFileNames {
+				absBaseTemplateFileName := filepath.Join(dirs[i], baseTemplateFileName)
+				if seenTemplateFileNames[absBaseTemplateFileName] {
+					continue
+				}
+
+				if fileInfo, err := os.Stat(absBaseTemplateFileName); err != nil || fileInfo.IsDir() {
+					continue
+				}
+
+				seenTemplateFileNames[absBaseTemplateFileName] = true
+
+				// Relative to where ain is run to keep fatals readable
+				if relBaseTemplateFileName, err := filepath.Rel(workingDir, absBaseTemplateFileName); err == nil {
+					baseTemplateFilenames = append(baseTemplateFilenames, relBaseTemplateFileName)
+				} else {
+					baseTemplateFilenames = append(baseTemplateFilenames, absBaseTemplateFileName)
+				}
+			}
+		}
+	}
+
+	return baseTemplateFilenames, nil
+}
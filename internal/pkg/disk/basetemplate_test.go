@@ -0,0 +1,67 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_GetBaseTemplateFilenames(t *testing.T) {
+	outsideDir := t.TempDir()
+	workspaceDir := filepath.Join(outsideDir, "workspace")
+
+	for _, dir := range []string{".git", "api/users", "api/.ain"} {
+		if err := os.MkdirAll(filepath.Join(workspaceDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workingDir, _ := os.Getwd()
+	defer os.Chdir(workingDir)
+
+	if err := os.Chdir(workspaceDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Above the workspace root, never picked up
+	if err := os.WriteFile(filepath.Join(outsideDir, "_base.ain"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, filename := range []string{"_base.ain", "api/_base.ain", "api/.ain/base.ain", "api/users/get-user.ain"} {
+		if err := os.WriteFile(filepath.Join(workspaceDir, filename), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]struct {
+		templateFileNames []string
+		expected          []string
+	}{
+		"Furthest up first": {
+			templateFileNames: []string{"api/users/get-user.ain"},
+			expected:          []string{"_base.ain", "api/_base.ain", filepath.Join("api", ".ain", "base.ain")},
+		},
+		"Given base template not returned": {
+			templateFileNames: []string{"api/_base.ain!", "api/users/get-user.ain"},
+			expected:          []string{"_base.ain", filepath.Join("api", ".ain", "base.ain")},
+		},
+		"Added once for several templates": {
+			templateFileNames: []string{"api/users/get-user.ain", "api/users/get-user.ain"},
+			expected:          []string{"_base.ain", "api/_base.ain", filepath.Join("api", ".ain", "base.ain")},
+		},
+	}
+
+	for name, test := range tests {
+		baseTemplateFilenames, err := GetBaseTemplateFilenames(test.templateFileNames)
+		if err != nil {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(baseTemplateFilenames, test.expected) {
+			t.Errorf("Test: %s. Unexpected base templates: %v", name, baseTemplateFilenames)
+		}
+	}
+}
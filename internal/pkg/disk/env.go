@@ -1,7 +1,12 @@
 package disk
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/hashicorp/go-envparse"
 	"github.com/pkg/errors"
@@ -39,3 +44,93 @@ func ReadEnvFile(path string, errorOnMissingFile bool) error {
 
 	return nil
 }
+
+const stateFileName = ".ain-state.env"
+
+// GetStateFilePath returns the file captured values are
+// written to, it lives next to the template file
+func GetStateFilePath(templateFileName string) string {
+	return filepath.Join(filepath.Dir(templateFileName), stateFileName)
+}
+
+func GetStateFilePaths(templateFileNames []string) []string {
+	stateFilePaths := []string{}
+	seenStateFilePaths := map[string]bool{}
+
+	for _, templateFileName := range templateFileNames {
+		stateFilePath := GetStateFilePath(templateFileName)
+		if seenStateFilePaths[stateFilePath] {
+			continue
+		}
+
+		seenStateFilePaths[stateFilePath] = true
+		stateFilePaths = append(stateFilePaths, stateFilePath)
+	}
+
+	return stateFilePaths
+}
+
+// QuoteEnvValue quotes the value so it is read back as is from an .env-file
+func QuoteEnvValue(value string) string {
+	var quotedValue bytes.Buffer
+
+	// envparse understands json escape sequences in double quotes
+	encoder := json.NewEncoder(&quotedValue)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+
+	return strings.TrimSuffix(quotedValue.String(), "\n")
+}
+
+// WriteEnvFileValues sets the key value pairs in the .env-file,
+// replacing any existing lines with the same key and keeping the rest
+func WriteEnvFileValues(path string, keyValues [][]string) error {
+	var envFileLines []string
+
+	fileContents, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "error reading .env-file "+path)
+	}
+
+	if len(fileContents) > 0 {
+		envFileLines = strings.Split(strings.TrimSuffix(string(fileContents), "\n"), "\n")
+	}
+
+	for _, keyValue := range keyValues {
+		key, value := keyValue[0], keyValue[1]
+		envFileLine := key + "=" + QuoteEnvValue(value)
+
+		keyLineRe := regexp.MustCompile(`^\s*(export\s+)?` + regexp.QuoteMeta(key) + `\s*=`)
+
+		replaced := false
+		for i, existingLine := range envFileLines {
+			if keyLineRe.MatchString(existingLine) {
+				envFileLines[i] = envFileLine
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			envFileLines = append(envFileLines, envFileLine)
+		}
+	}
+
+	envFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "error writing .env-file "+path)
+	}
+
+	// Captured values are often secrets such as tokens. The
+	// mode above is only set when the file is created.
+	if err := envFile.Chmod(0600); err != nil {
+		_ = envFile.Close()
+		return errors.Wrap(err, "error setting permissions on .env-file "+path)
+	}
+
+	if _, err := envFile.WriteString(strings.Join(envFileLines, "\n") + "\n"); err != nil {
+		_ = envFile.Close()
+		return errors.Wrap(err, "error writing .env-file "+path)
+	}
+
+	return errors.Wrap(envFile.Close(), "error writing .env-file "+path)
+}
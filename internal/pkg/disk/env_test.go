@@ -0,0 +1,37 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_WriteEnvFileValues(t *testing.T) {
+	envFilePath := filepath.Join(t.TempDir(), ".ain-state.env")
+	if err := os.WriteFile(envFilePath, []byte("# state\nexport TOKEN=old\nUSER=ain\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteEnvFileValues(envFilePath, [][]string{{"TOKEN", "t0k3n"}, {"ID", "1"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	contents, err := os.ReadFile(envFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expectedContents := "# state\nTOKEN=\"t0k3n\"\nUSER=ain\nID=\"1\"\n"; string(contents) != expectedContents {
+		t.Errorf("Unexpected contents: %q", contents)
+	}
+
+	// Also an existing world-readable file is made user-only
+	stat, err := os.Stat(envFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stat.Mode().Perm() != 0600 {
+		t.Errorf("Unexpected mode: %v", stat.Mode().Perm())
+	}
+}
@@ -0,0 +1,124 @@
+package disk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func getExecCacheDir() (string, error) {
+	// os.UserCacheDir only honours XDG_CACHE_HOME on unix
+	if xdgCacheHome := os.Getenv("XDG_CACHE_HOME"); xdgCacheHome != "" {
+		return filepath.Join(xdgCacheHome, "ain"), nil
+	}
+
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "cannot find the cache dir")
+	}
+
+	return filepath.Join(userCacheDir, "ain"), nil
+}
+
+func getCacheKey(parts []string) string {
+	hash := sha256.New()
+
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// GetExecCacheKey returns the key for the output of an executable.
+// The output can depend on the working dir and the environment
+// so both are part of the key.
+func GetExecCacheKey(executable string, args []string) string {
+	workingDir, _ := os.Getwd()
+	environment := os.Environ()
+	sort.Strings(environment)
+
+	return getCacheKey(append(append([]string{workingDir, executable}, args...), environment...))
+}
+
+// ReadExecCache returns the cached output if
+// it was written less than maxAge ago
+func ReadExecCache(key string, maxAge time.Duration) (string, bool) {
+	execCacheDir, err := getExecCacheDir()
+	if err != nil {
+		return "", false
+	}
+
+	cacheFilePath := filepath.Join(execCacheDir, key)
+
+	fileInfo, err := os.Stat(cacheFilePath)
+	if err != nil || time.Since(fileInfo.ModTime()) > maxAge {
+		return "", false
+	}
+
+	output, err := os.ReadFile(cacheFilePath)
+	if err != nil {
+		return "", false
+	}
+
+	return string(output), true
+}
+
+func WriteExecCache(key, output string) error {
+	execCacheDir, err := getExecCacheDir()
+	if err != nil {
+		return err
+	}
+
+	return writeCacheFile(execCacheDir, key, output)
+}
+
+func writeCacheFile(cacheDir, key, contents string) error {
+	// Output is often tokens, so only the user can read it
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return errors.Wrap(err, "cannot create the cache dir "+cacheDir)
+	}
+
+	// Written to a temp-file (created 0600) and renamed
+	// so other ain processes never read half a file
+	cacheFile, err := os.CreateTemp(cacheDir, key+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "cannot create a cache file in "+cacheDir)
+	}
+
+	_, err = cacheFile.WriteString(contents)
+	if closeErr := cacheFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(cacheFile.Name())
+		return errors.Wrap(err, "cannot write the cache file "+cacheFile.Name())
+	}
+
+	if err := os.Rename(cacheFile.Name(), filepath.Join(cacheDir, key)); err != nil {
+		os.Remove(cacheFile.Name())
+		return errors.Wrap(err, "cannot write the cache file "+cacheFile.Name())
+	}
+
+	return nil
+}
+
+func ClearExecCache() error {
+	execCacheDir, err := getExecCacheDir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(execCacheDir); err != nil {
+		return errors.Wrap(err, "cannot clear the cache dir "+execCacheDir)
+	}
+
+	return nil
+}
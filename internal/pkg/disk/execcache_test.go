@@ -0,0 +1,110 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func assertPerm(t *testing.T, name string, expectedPerm os.FileMode) {
+	t.Helper()
+
+	fileInfo, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fileInfo.Mode().Perm() != expectedPerm {
+		t.Errorf("Unexpected mode of %s: %v", name, fileInfo.Mode().Perm())
+	}
+}
+
+func Test_ExecCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	execCacheDir := filepath.Join(os.Getenv("XDG_CACHE_HOME"), "ain")
+
+	key := GetExecCacheKey("echo", []string{"t0k3n"})
+	if err := WriteExecCache(key, "t0k3n\n"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assertPerm(t, execCacheDir, 0700)
+	assertPerm(t, filepath.Join(execCacheDir, key), 0600)
+
+	if output, found := ReadExecCache(key, time.Minute); !found || output != "t0k3n\n" {
+		t.Errorf("Expected cached output, got: %q %v", output, found)
+	}
+
+	if _, found := ReadExecCache(GetExecCacheKey("echo", []string{"other"}), time.Minute); found {
+		t.Errorf("Expected other args to miss the cache")
+	}
+
+	twoMinutesAgo := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(filepath.Join(execCacheDir, key), twoMinutesAgo, twoMinutesAgo); err != nil {
+		t.Fatal(err)
+	}
+
+	if output, found := ReadExecCache(key, time.Minute); found {
+		t.Errorf("Expected cached output to have expired, got: %q", output)
+	}
+
+	if _, found := ReadExecCache(key, time.Hour); !found {
+		t.Errorf("Expected cached output within a longer max age")
+	}
+}
+
+func Test_ClearExecCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	execKey := GetExecCacheKey("echo", []string{"t0k3n"})
+	if err := WriteExecCache(execKey, "t0k3n\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	tokenKey := GetTokenCacheKey("https://example.com/token", "client")
+	if err := WriteTokenCache(tokenKey, CachedToken{AccessToken: "t0k3n"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ClearExecCache(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, found := ReadExecCache(execKey, time.Hour); found {
+		t.Errorf("Expected cached output to be cleared")
+	}
+
+	if _, found := ReadTokenCache(tokenKey); found {
+		t.Errorf("Expected cached token to be cleared")
+	}
+
+	// Clearing a cache that does not exist is fine
+	if err := ClearExecCache(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_TokenCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	tokenCacheDir := filepath.Join(os.Getenv("XDG_CACHE_HOME"), "ain", "tokens")
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	cachedToken := CachedToken{AccessToken: "t0k3n", RefreshToken: "r3fr3sh", ExpiresAt: expiresAt}
+
+	key := GetTokenCacheKey("https://example.com/token", "client")
+	if err := WriteTokenCache(key, cachedToken); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assertPerm(t, tokenCacheDir, 0700)
+	assertPerm(t, filepath.Join(tokenCacheDir, key), 0600)
+
+	if readToken, found := ReadTokenCache(key); !found || readToken != cachedToken {
+		t.Errorf("Unexpected cached token: %+v %v", readToken, found)
+	}
+
+	if _, found := ReadTokenCache(GetTokenCacheKey("https://example.com/token", "other-client")); found {
+		t.Errorf("Expected another client to miss the cache")
+	}
+}
@@ -34,3 +34,22 @@ func GetTemplateFilenames(cmdParamTemplateFileNames []string) ([]string, error)
 
 	return cmdParamTemplateFileNames, nil
 }
+
+// ReadPipedStdin returns anything piped to ain, nothing is read from a terminal
+func ReadPipedStdin() (string, error) {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return "", errors.Wrap(err, "could not stat stdin")
+	}
+
+	if (fi.Mode() & os.ModeCharDevice) != 0 {
+		return "", nil
+	}
+
+	stdinBytes, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", errors.Wrap(err, "could not read pipe stdin")
+	}
+
+	return string(stdinBytes), nil
+}
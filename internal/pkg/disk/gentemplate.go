@@ -10,7 +10,7 @@ import (
 	"github.com/pkg/errors"
 )
 
-var backendPrioOrder = []string{"curl", "httpie", "wget"}
+var backendPrioOrder = []string{"curl", "httpie", "wget", "native"}
 
 var starterTemplate = `[Host]
 http://localhost:${PORT}
@@ -33,11 +33,7 @@ Content-Type: application/json
 Timeout=3
 # queryDelim=&
 
-[Backend]
-{{Backends}}
-
-[BackendOptions]
-{{BackendOptions}}
+{{BackendSections}}
 
 # Short help:
 # Comments start with hash-sign (#) and are ignored
@@ -64,6 +60,13 @@ func getPresentBackendBinaries() ([]string, []string) {
 
 	for _, backendTemplateName := range backendPrioOrder {
 		backendConstructor := call.ValidBackends[backendTemplateName]
+
+		// The native backend needs no binary and is always present
+		if backendConstructor.BinaryName == "" {
+			presentBackends = append(presentBackends, backendTemplateName)
+			continue
+		}
+
 		if _, err := exec.LookPath(backendConstructor.BinaryName); err == nil {
 			presentBackends = append(presentBackends, backendTemplateName)
 
@@ -76,31 +79,33 @@ func getPresentBackendBinaries() ([]string, []string) {
 	return presentBackends, usefulBackendOptions
 }
 
-func GenerateEmptyTemplates(templateFileNames []string) error {
+// GetBackendSections returns a [Backend] section with the installed
+// backends, all but the first commented out, and [BackendOptions]
+// making their output fit for a terminal.
+func GetBackendSections() string {
 	presentBackends, usefulBackendOptions := getPresentBackendBinaries()
-	if len(presentBackends) == 0 {
-		presentBackends = []string{`# No backend binaries found, please install at least one.
-# See here for more help: https://github.com/jonaslu/ain#pre-requisites`}
-	} else {
-		for i := 1; i < len(presentBackends); i++ {
-			presentBackends[i] = "# " + presentBackends[i]
-		}
 
-		for i := 1; i < len(usefulBackendOptions); i++ {
-			usefulBackendOptions[i] = "# " + usefulBackendOptions[i]
-		}
+	for i := 1; i < len(presentBackends); i++ {
+		presentBackends[i] = "# " + presentBackends[i]
 	}
 
-	// text/template is too complicated for this, we're replacing strings until it feels too heavy
-	starterTemplate = strings.ReplaceAll(starterTemplate, "{{Backends}}", strings.Join(presentBackends, "\n"))
+	for i := 1; i < len(usefulBackendOptions); i++ {
+		usefulBackendOptions[i] = "# " + usefulBackendOptions[i]
+	}
+
+	backendSections := "[Backend]\n" + strings.Join(presentBackends, "\n")
 
 	if len(usefulBackendOptions) > 0 {
-		starterTemplate = strings.ReplaceAll(starterTemplate, "{{BackendOptions}}", strings.Join(usefulBackendOptions, "\n"))
-	} else {
-		starterTemplate = strings.ReplaceAll(starterTemplate, "[BackendOptions]\n", "")
-		starterTemplate = strings.ReplaceAll(starterTemplate, "{{BackendOptions}}\n\n", "")
+		backendSections += "\n\n[BackendOptions]\n" + strings.Join(usefulBackendOptions, "\n")
 	}
 
+	return backendSections
+}
+
+func GenerateEmptyTemplates(templateFileNames []string) error {
+	// text/template is too complicated for this, we're replacing strings until it feels too heavy
+	starterTemplate := strings.ReplaceAll(starterTemplate, "{{BackendSections}}", GetBackendSections())
+
 	if len(templateFileNames) == 0 {
 		_, err := fmt.Fprintln(os.Stdout, starterTemplate)
 		return err
@@ -0,0 +1,49 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/pkg/errors"
+)
+
+// WriteImportedFiles writes nothing if any of the files already exists
+// or if two imported files would be written to the same file
+func WriteImportedFiles(folder string, importedFiles []data.ImportedFile) error {
+	filenames := map[string]bool{}
+
+	for _, importedFile := range importedFiles {
+		filename := filepath.Join(folder, importedFile.Filename)
+
+		if filenames[filename] {
+			return errors.Errorf("cannot import, file %s would be written twice", filename)
+		}
+		filenames[filename] = true
+
+		if _, err := os.Stat(filename); !os.IsNotExist(err) {
+			return errors.Errorf("cannot import, file already exists %s", filename)
+		}
+	}
+
+	for _, importedFile := range importedFiles {
+		filename := filepath.Join(folder, importedFile.Filename)
+
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return errors.Wrapf(err, "could not create folder for imported file %s", filename)
+		}
+
+		// Variables are often secrets such as tokens
+		perm := os.FileMode(0644)
+		if strings.HasPrefix(filepath.Base(filename), ".env") {
+			perm = 0600
+		}
+
+		if err := os.WriteFile(filename, []byte(importedFile.Contents), perm); err != nil {
+			return errors.Wrapf(err, "could not write imported file %s", filename)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_WriteImportedFiles(t *testing.T) {
+	folder := t.TempDir()
+
+	err := WriteImportedFiles(folder, []data.ImportedFile{
+		{Filename: "api/_base.ain", Contents: "[Host]\nhttps://api.example.com\n"},
+		{Filename: "api/.env", Contents: "TOKEN=t0k3n\n"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assertPerm(t, filepath.Join(folder, "api", "_base.ain"), 0644)
+
+	// Variables are often secrets
+	assertPerm(t, filepath.Join(folder, "api", ".env"), 0600)
+
+	err = WriteImportedFiles(folder, []data.ImportedFile{
+		{Filename: "api/get-users.ain", Contents: "[Host]\n/users\n"},
+		{Filename: "api/_base.ain", Contents: "[Host]\nhttp://api.example.com\n"},
+	})
+
+	expectedError := "cannot import, file already exists " + filepath.Join(folder, "api", "_base.ain")
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(folder, "api", "get-users.ain")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written, got: %v", err)
+	}
+
+	contents, _ := os.ReadFile(filepath.Join(folder, "api", "_base.ain"))
+	if string(contents) != "[Host]\nhttps://api.example.com\n" {
+		t.Errorf("Expected existing file to be left as is, got: %q", contents)
+	}
+}
+
+func Test_WriteImportedFilesDuplicateFilenames(t *testing.T) {
+	folder := t.TempDir()
+
+	err := WriteImportedFiles(folder, []data.ImportedFile{
+		{Filename: "api/_base.ain", Contents: "[Host]\nhttps://api.example.com\n"},
+		{Filename: "api/_base.ain", Contents: "[Host]\nhttp://api.example.com\n"},
+	})
+
+	expectedError := "cannot import, file " + filepath.Join(folder, "api", "_base.ain") + " would be written twice"
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(folder, "api")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written, got: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package disk
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type CachedToken struct {
+	AccessToken string `json:"access_token"`
+	// Set if the token endpoint rotated the refresh token
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func getTokenCacheDir() (string, error) {
+	execCacheDir, err := getExecCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(execCacheDir, "tokens"), nil
+}
+
+// GetTokenCacheKey returns the key for a token fetched
+// with the parts (token url, client id etc)
+func GetTokenCacheKey(parts ...string) string {
+	return getCacheKey(parts)
+}
+
+// ReadTokenCache returns the cached token, expired or not
+// as the refresh token can outlive the access token
+func ReadTokenCache(key string) (CachedToken, bool) {
+	cachedToken := CachedToken{}
+
+	tokenCacheDir, err := getTokenCacheDir()
+	if err != nil {
+		return cachedToken, false
+	}
+
+	cachedTokenBytes, err := os.ReadFile(filepath.Join(tokenCacheDir, key))
+	if err != nil {
+		return cachedToken, false
+	}
+
+	if err := json.Unmarshal(cachedTokenBytes, &cachedToken); err != nil {
+		return cachedToken, false
+	}
+
+	return cachedToken, true
+}
+
+func WriteTokenCache(key string, cachedToken CachedToken) error {
+	tokenCacheDir, err := getTokenCacheDir()
+	if err != nil {
+		return err
+	}
+
+	cachedTokenBytes, err := json.Marshal(cachedToken)
+	if err != nil {
+		return errors.Wrap(err, "cannot encode the token for the cache")
+	}
+
+	return writeCacheFile(tokenCacheDir, key, string(cachedTokenBytes))
+}
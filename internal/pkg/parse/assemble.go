@@ -4,17 +4,18 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/jonaslu/ain/internal/pkg/call"
 	"github.com/jonaslu/ain/internal/pkg/data"
-	"github.com/jonaslu/ain/internal/pkg/disk"
 )
 
 const editFileSuffix = "!"
 
-func getAllSectionedTemplates(filenames []string) ([]*sectionedTemplate, error) {
-	allSectionedTemplates := []*sectionedTemplate{}
+func getTemplateChain(filenames []string) (templateChain, error) {
+	chain := newTemplateChain()
 
 	for _, filename := range filenames {
 		editFile := false
@@ -24,15 +25,23 @@ func getAllSectionedTemplates(filenames []string) ([]*sectionedTemplate, error)
 			filename = strings.TrimSuffix(filename, editFileSuffix)
 		}
 
-		rawTemplateString, err := disk.ReadRawTemplateString(filename, editFile)
-		if err != nil {
-			return nil, err
+		filename, requestName := splitRequestName(filename)
+
+		if err := chain.addTemplateFile(filename, requestName, editFile, nil); err != nil {
+			return chain, err
 		}
+	}
+
+	return chain, nil
+}
 
-		allSectionedTemplates = append(allSectionedTemplates, newSectionedTemplate(rawTemplateString, filename))
+func getAllSectionedTemplates(filenames []string) ([]*sectionedTemplate, []string, error) {
+	chain, err := getTemplateChain(filenames)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return allSectionedTemplates, nil
+	return chain.sectionedTemplates, chain.fatals, nil
 }
 
 func getConfig(allSectionedTemplates []*sectionedTemplate) (data.Config, []string) {
@@ -61,7 +70,15 @@ func getConfig(allSectionedTemplates []*sectionedTemplate) (data.Config, []strin
 			config.QueryDelim = localConfig.QueryDelim
 		}
 
-		if config.Timeout > data.TimeoutNotSet && config.QueryDelim != nil {
+		if config.ExecCache == data.ExecCacheNotSet {
+			config.ExecCache = localConfig.ExecCache
+		}
+
+		if config.BodyMerge == data.BodyMergeNotSet {
+			config.BodyMerge = localConfig.BodyMerge
+		}
+
+		if config.Timeout > data.TimeoutNotSet && config.QueryDelim != nil && config.ExecCache != data.ExecCacheNotSet && config.BodyMerge != data.BodyMergeNotSet {
 			break
 		}
 	}
@@ -72,8 +89,12 @@ func getConfig(allSectionedTemplates []*sectionedTemplate) (data.Config, []strin
 func substituteEnvVars(allSectionedTemplates []*sectionedTemplate) []string {
 	substituteEnvVarsFatals := []string{}
 
+	// Variables declared in [Vars] are visible in the
+	// declaring template and all templates after it
+	vars := newTemplateVars()
+
 	for _, sectionedTemplate := range allSectionedTemplates {
-		if sectionedTemplate.substituteEnvVars(); sectionedTemplate.hasFatalMessages() {
+		if sectionedTemplate.substituteEnvVars(vars); sectionedTemplate.hasFatalMessages() {
 			substituteEnvVarsFatals = append(substituteEnvVarsFatals, sectionedTemplate.getFatalMessages())
 		}
 	}
@@ -85,9 +106,32 @@ func substituteExecutables(ctx context.Context, config data.Config, allSectioned
 	substituteExecutablesFatals := []string{}
 	allExecutableAndArgs := []executableAndArgs{}
 
+	// Named executables can be referenced before they're
+	// defined, so references are resolved when all are captured
+	namedExecutables := map[string]*namedExecutable{}
+
 	for _, sectionedTemplate := range allSectionedTemplates {
-		allExecutableAndArgs = append(allExecutableAndArgs, sectionedTemplate.captureExecutableAndArgs()...)
+		allExecutableAndArgs = append(allExecutableAndArgs, sectionedTemplate.captureExecutableAndArgs(namedExecutables)...)
+	}
+
+	executableNames := []string{}
+	for name := range namedExecutables {
+		executableNames = append(executableNames, name)
+	}
+
+	sort.Strings(executableNames)
 
+	for _, name := range executableNames {
+		if namedExecutables[name].definition != nil {
+			continue
+		}
+
+		for _, reference := range namedExecutables[name].references {
+			reference.sectionedTemplate.setFatalMessage(fmt.Sprintf("Cannot find executable %s%s", executableNamePrefix, name), reference.expandedTemplateLineIndex)
+		}
+	}
+
+	for _, sectionedTemplate := range allSectionedTemplates {
 		if sectionedTemplate.hasFatalMessages() {
 			substituteExecutablesFatals = append(substituteExecutablesFatals, sectionedTemplate.getFatalMessages())
 		}
@@ -97,6 +141,12 @@ func substituteExecutables(ctx context.Context, config data.Config, allSectioned
 		return substituteExecutablesFatals, nil
 	}
 
+	for i, executable := range allExecutableAndArgs {
+		if executable.name != "" {
+			allExecutableAndArgs[i] = *namedExecutables[executable.name].definition
+		}
+	}
+
 	allExecutablesOutput := callExecutables(ctx, config, allExecutableAndArgs)
 	if ctx.Err() == context.Canceled {
 		return nil, ctx.Err()
@@ -118,23 +168,76 @@ type allSectionRows struct {
 	headers        []string
 	query          []string
 	body           []string
+	bodyFileName   string
+	form           []string
+	multipart      []data.MultipartField
+	graphQLQuery   string
+	graphQLVars    interface{}
 	backendOptions [][]string
+	assertions     []data.Assertion
+	captures       []data.Capture
+	auth           *auth
+	warnings       []string
 }
 
-func getAllSectionRows(allSectionedTemplates []*sectionedTemplate) (allSectionRows, []string) {
+func getAllSectionRows(allSectionedTemplates []*sectionedTemplate, config data.Config) (allSectionRows, []string) {
 	allSectionRowsFatals := []string{}
 	allSectionRows := allSectionRows{}
 
+	// Nil until a [Body] is merged
+	var mergedJsonBody interface{}
+
 	for _, sectionedTemplate := range allSectionedTemplates {
 		if sectionedTemplate.setCapturedSections(sectionsAllowingExecutables...); sectionedTemplate.hasFatalMessages() {
 			allSectionRowsFatals = append(allSectionRowsFatals, sectionedTemplate.getFatalMessages())
 			continue
 		}
 
+		if sectionedTemplate.replacedSections[hostSection] {
+			allSectionRows.host = ""
+		}
+
+		if sectionedTemplate.replacedSections[headersSection] {
+			allSectionRows.headers = nil
+		}
+
+		if sectionedTemplate.replacedSections[querySection] {
+			allSectionRows.query = nil
+		}
+
+		if sectionedTemplate.replacedSections[backendOptionsSection] {
+			allSectionRows.backendOptions = nil
+		}
+
+		localHeaders, removedHeaderNames := sectionedTemplate.getHeaders()
+		localQuery, removedQueryKeys := sectionedTemplate.getQuery()
+		localBackendOptions, removedBackendOptions := sectionedTemplate.getBackendOptions()
+
+		if allSectionRows.auth != nil && allSectionRows.auth.isRemovedBy(removedHeaderNames, removedQueryKeys) {
+			allSectionRows.auth = nil
+		}
+
+		if headerWarnings := sectionedTemplate.getHeaderWarnings(); len(headerWarnings) > 0 {
+			allSectionRows.warnings = append(allSectionRows.warnings, "Warning in file: "+sectionedTemplate.filename+"\n"+strings.Join(headerWarnings, "\n\n"))
+		}
+
+		localForm := sectionedTemplate.getForm()
+		localMultipart := sectionedTemplate.getMultipart()
+
+		// The body type is set by the [Form] or [Multipart], not
+		// by an inherited Content-Type (e g json in a base template)
+		if len(localForm) > 0 || len(localMultipart) > 0 {
+			removedHeaderNames = append(removedHeaderNames, "Content-Type")
+		}
+
 		allSectionRows.host = allSectionRows.host + sectionedTemplate.getHost()
-		allSectionRows.headers = append(allSectionRows.headers, sectionedTemplate.getHeaders()...)
-		allSectionRows.query = append(allSectionRows.query, sectionedTemplate.getQuery()...)
-		allSectionRows.backendOptions = append(allSectionRows.backendOptions, sectionedTemplate.getBackendOptions()...)
+		allSectionRows.headers = append(removeHeaders(allSectionRows.headers, removedHeaderNames), localHeaders...)
+		allSectionRows.query = append(removeQuery(allSectionRows.query, removedQueryKeys), localQuery...)
+		allSectionRows.form = append(allSectionRows.form, localForm...)
+		allSectionRows.multipart = append(allSectionRows.multipart, localMultipart...)
+		allSectionRows.backendOptions = append(removeBackendOptions(allSectionRows.backendOptions, removedBackendOptions), localBackendOptions...)
+		allSectionRows.assertions = append(allSectionRows.assertions, sectionedTemplate.getAssertions()...)
+		allSectionRows.captures = append(allSectionRows.captures, sectionedTemplate.getCaptures()...)
 
 		if localBackend := sectionedTemplate.getBackend(); localBackend != "" {
 			allSectionRows.backend = localBackend
@@ -144,8 +247,41 @@ func getAllSectionRows(allSectionedTemplates []*sectionedTemplate) (allSectionRo
 			allSectionRows.method = localMethod
 		}
 
-		if localBody := sectionedTemplate.getBody(); len(localBody) > 0 {
+		localBody := sectionedTemplate.getBody()
+		localBodyFile := sectionedTemplate.getBodyFile()
+
+		if len(localBody) > 0 && localBodyFile != nil {
+			sectionedTemplate.setFatalMessage("Found both [Body] and [BodyFile], use one of them", (*sectionedTemplate.getNamedSection(bodyFileSection))[0].sourceLineIndex)
+		}
+
+		if len(localBody) > 0 {
 			allSectionRows.body = localBody
+			allSectionRows.bodyFileName = ""
+
+			if config.BodyMerge == data.BodyMergeJson {
+				if jsonBody, ok := sectionedTemplate.getJsonBody(); ok {
+					mergedJsonBody = mergeJsonValues(mergedJsonBody, jsonBody)
+				}
+			}
+		}
+
+		// An expanded body file is the [Body] of the next template
+		if localBodyFile != nil && !localBodyFile.expand {
+			allSectionRows.bodyFileName = localBodyFile.filename
+			allSectionRows.body = nil
+			mergedJsonBody = nil
+		}
+
+		if localGraphQLQuery := sectionedTemplate.getGraphQLQuery(); localGraphQLQuery != "" {
+			allSectionRows.graphQLQuery = localGraphQLQuery
+		}
+
+		if localGraphQLVariables := sectionedTemplate.getGraphQLVariables(); localGraphQLVariables != nil {
+			allSectionRows.graphQLVars = localGraphQLVariables
+		}
+
+		if localAuth := sectionedTemplate.getAuth(); localAuth != nil {
+			allSectionRows.auth = localAuth
 		}
 
 		if sectionedTemplate.hasFatalMessages() {
@@ -153,6 +289,59 @@ func getAllSectionRows(allSectionedTemplates []*sectionedTemplate) (allSectionRo
 		}
 	}
 
+	if mergedJsonBody != nil {
+		allSectionRows.body = strings.Split(formatJsonValue(mergedJsonBody), "\n")
+	}
+
+	if allSectionRows.backend == "" {
+		return allSectionRows, allSectionRowsFatals
+	}
+
+	if auth := allSectionRows.auth; auth != nil && auth.apiKey == nil && !call.SupportsAuthScheme(allSectionRows.backend, auth.auth.Scheme) {
+		fatal := fmt.Sprintf("Backend %s does not support %s auth", allSectionRows.backend, auth.auth.Scheme)
+		allSectionRowsFatals = append(allSectionRowsFatals, "Fatal error in file: "+auth.filename+"\n"+auth.formatFatal(fatal))
+	}
+
+	if nonBodyOutputOption := call.GetNonBodyOutputOption(allSectionRows.backend, allSectionRows.backendOptions); nonBodyOutputOption != "" {
+		nonBodyOutputFatal := func(selector data.ResponseSelector, filename string, formatFatal func(string) string) {
+			if selector.Subject != data.BodySubject {
+				return
+			}
+
+			fatal := fmt.Sprintf("Backend option %s puts more than the response body on stdout, remove it to check the body", nonBodyOutputOption)
+			allSectionRowsFatals = append(allSectionRowsFatals, "Fatal error in file: "+filename+"\n"+formatFatal(fatal))
+		}
+
+		for _, assertion := range allSectionRows.assertions {
+			nonBodyOutputFatal(assertion.Selector, assertion.Filename, assertion.FormatFatal)
+		}
+
+		for _, capture := range allSectionRows.captures {
+			nonBodyOutputFatal(capture.Selector, capture.Filename, capture.FormatFatal)
+		}
+	}
+
+	if call.ReportsResponseHead(allSectionRows.backend) {
+		return allSectionRows, allSectionRowsFatals
+	}
+
+	backendCannotReportFatal := func(selector data.ResponseSelector, filename string, formatFatal func(string) string) {
+		if selector.Subject == data.BodySubject {
+			return
+		}
+
+		fatal := fmt.Sprintf("Backend %s cannot report the response %s, use curl or native", allSectionRows.backend, selector.Subject)
+		allSectionRowsFatals = append(allSectionRowsFatals, "Fatal error in file: "+filename+"\n"+formatFatal(fatal))
+	}
+
+	for _, assertion := range allSectionRows.assertions {
+		backendCannotReportFatal(assertion.Selector, assertion.Filename, assertion.FormatFatal)
+	}
+
+	for _, capture := range allSectionRows.captures {
+		backendCannotReportFatal(capture.Selector, capture.Filename, capture.FormatFatal)
+	}
+
 	return allSectionRows, allSectionRowsFatals
 }
 
@@ -160,6 +349,19 @@ func getBackendInput(allSectionRows allSectionRows, config data.Config) (*data.B
 	backendInputFatals := []string{}
 	backendInput := data.BackendInput{}
 
+	if auth := allSectionRows.auth; auth != nil {
+		if auth.apiKey == nil {
+			backendInput.Auth = &auth.auth
+		} else if auth.apiKey.in == apiKeyInHeader {
+			// First, so a header of the same name in [Headers] wins
+			allSectionRows.headers = append([]string{auth.apiKey.name + ": " + auth.apiKey.value}, allSectionRows.headers...)
+		} else {
+			allSectionRows.query = append(allSectionRows.query, auth.apiKey.name+queryKeyValueDelim+auth.apiKey.value)
+		}
+	}
+
+	allSectionRows.headers = dedupeHeaders(allSectionRows.headers)
+
 	if allSectionRows.host == "" {
 		backendInputFatals = append(backendInputFatals, "No mandatory [Host] section found")
 	} else {
@@ -177,47 +379,116 @@ func getBackendInput(allSectionRows allSectionRows, config data.Config) (*data.B
 		backendInputFatals = append(backendInputFatals, "No mandatory [Backend] section found")
 	}
 
+	bodySectionName := "[Body]"
+	if allSectionRows.bodyFileName != "" {
+		bodySectionName = "[BodyFile]"
+	}
+
+	hasBody := len(allSectionRows.body) > 0 || allSectionRows.bodyFileName != ""
+
+	if hasBody && len(allSectionRows.form) > 0 {
+		backendInputFatals = append(backendInputFatals, "Found both "+bodySectionName+" and [Form], use one of them")
+	}
+
+	if hasBody && len(allSectionRows.multipart) > 0 {
+		backendInputFatals = append(backendInputFatals, "Found both "+bodySectionName+" and [Multipart], use one of them")
+	}
+
+	if len(allSectionRows.form) > 0 && len(allSectionRows.multipart) > 0 {
+		backendInputFatals = append(backendInputFatals, "Found both [Form] and [Multipart], use one of them")
+	}
+
+	if len(allSectionRows.form) > 0 {
+		allSectionRows.body = []string{encodeKeyValues(allSectionRows.form, defaultQueryDelim, querySectionKeyValueDelimRegexp)}
+
+		if !hasHeader(allSectionRows.headers, "Content-Type") {
+			allSectionRows.headers = append(allSectionRows.headers, "Content-Type: application/x-www-form-urlencoded")
+		}
+	}
+
+	hasGraphQL := allSectionRows.graphQLQuery != ""
+
+	if hasGraphQL && hasBody {
+		backendInputFatals = append(backendInputFatals, "Found both "+bodySectionName+" and [GraphQL], use one of them")
+	}
+
+	if hasGraphQL && len(allSectionRows.form) > 0 {
+		backendInputFatals = append(backendInputFatals, "Found both [Form] and [GraphQL], use one of them")
+	}
+
+	if hasGraphQL && len(allSectionRows.multipart) > 0 {
+		backendInputFatals = append(backendInputFatals, "Found both [Multipart] and [GraphQL], use one of them")
+	}
+
+	if !hasGraphQL && allSectionRows.graphQLVars != nil {
+		backendInputFatals = append(backendInputFatals, "Found [GraphQLVariables] but no [GraphQL] section")
+	}
+
+	if hasGraphQL {
+		allSectionRows.body = getGraphQLBody(allSectionRows.graphQLQuery, allSectionRows.graphQLVars)
+
+		if !hasHeader(allSectionRows.headers, "Content-Type") {
+			allSectionRows.headers = append(allSectionRows.headers, "Content-Type: application/json")
+		}
+
+		if allSectionRows.method == "" {
+			allSectionRows.method = "POST"
+		}
+	}
+
 	backendInput.Method = allSectionRows.method
 	backendInput.Body = allSectionRows.body
+	backendInput.BodyFileName = allSectionRows.bodyFileName
+	backendInput.Multipart = allSectionRows.multipart
 	backendInput.Headers = allSectionRows.headers
 	backendInput.Backend = allSectionRows.backend
 	backendInput.BackendOptions = allSectionRows.backendOptions
+	backendInput.Assertions = allSectionRows.assertions
+	backendInput.Captures = allSectionRows.captures
+	backendInput.Warnings = allSectionRows.warnings
 
 	return &backendInput, backendInputFatals
 }
 
-func Assemble(ctx context.Context, filenames []string) (context.Context, *data.BackendInput, string, error) {
-	allSectionedTemplates, err := getAllSectionedTemplates(filenames)
+// The returned cancel func releases the [Config] timeout, if any
+func Assemble(ctx context.Context, filenames []string) (context.Context, context.CancelFunc, *data.BackendInput, string, error) {
+	cancel := context.CancelFunc(func() {})
+
+	allSectionedTemplates, allSectionedTemplatesFatals, err := getAllSectionedTemplates(filenames)
 	if err != nil {
-		return ctx, nil, "", err
+		return ctx, cancel, nil, "", err
+	}
+
+	if len(allSectionedTemplatesFatals) > 0 {
+		return ctx, cancel, nil, strings.Join(allSectionedTemplatesFatals, "\n\n"), nil
 	}
 
 	if substituteEnvVarsFatals := substituteEnvVars(allSectionedTemplates); len(substituteEnvVarsFatals) > 0 {
-		return ctx, nil, strings.Join(substituteEnvVarsFatals, "\n\n"), nil
+		return ctx, cancel, nil, strings.Join(substituteEnvVarsFatals, "\n\n"), nil
 	}
 
 	config, configFatals := getConfig(allSectionedTemplates)
 	if len(configFatals) > 0 {
-		return ctx, nil, strings.Join(configFatals, "\n\n"), nil
+		return ctx, cancel, nil, strings.Join(configFatals, "\n\n"), nil
 	}
 
 	if config.Timeout != data.TimeoutNotSet {
-		ctx, _ = context.WithTimeout(ctx, time.Duration(config.Timeout)*time.Second)
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.Timeout)*time.Second)
 		ctx = context.WithValue(ctx, data.TimeoutContextValueKey{}, config.Timeout)
 	}
 
 	substituteExecutablesFatals, err := substituteExecutables(ctx, config, allSectionedTemplates)
 	if err != nil {
-		return ctx, nil, "", err
+		return ctx, cancel, nil, "", err
 	}
 
 	if len(substituteExecutablesFatals) > 0 {
-		return ctx, nil, strings.Join(substituteExecutablesFatals, "\n\n"), nil
+		return ctx, cancel, nil, strings.Join(substituteExecutablesFatals, "\n\n"), nil
 	}
 
-	allSectionRows, allSectionRowsFatals := getAllSectionRows(allSectionedTemplates)
+	allSectionRows, allSectionRowsFatals := getAllSectionRows(allSectionedTemplates, config)
 	if len(allSectionRowsFatals) > 0 {
-		return ctx, nil, strings.Join(allSectionRowsFatals, "\n\n"), nil
+		return ctx, cancel, nil, strings.Join(allSectionRowsFatals, "\n\n"), nil
 	}
 
 	backendInput, backendInputFatals := getBackendInput(allSectionRows, config)
@@ -225,8 +496,20 @@ func Assemble(ctx context.Context, filenames []string) (context.Context, *data.B
 		// Since we no longer have a sectionedTemplate errors
 		// are no longer linked to a file and we separate
 		// with one newline
-		return ctx, nil, strings.Join(backendInputFatals, "\n"), nil
+		return ctx, cancel, nil, strings.Join(backendInputFatals, "\n"), nil
+	}
+
+	if backendInput.Auth != nil && backendInput.Auth.OAuth2 != nil {
+		token := printedOAuth2Token
+
+		if ctx.Value(data.PrintOnlyContextValueKey{}) == nil {
+			if token, err = getOAuth2Token(ctx, backendInput.Auth.OAuth2); err != nil {
+				return ctx, cancel, nil, "", err
+			}
+		}
+
+		backendInput.Auth = &data.Auth{Scheme: data.BearerAuthScheme, Token: token}
 	}
 
-	return ctx, backendInput, "", nil
+	return ctx, cancel, backendInput, "", nil
 }
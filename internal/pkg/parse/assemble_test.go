@@ -0,0 +1,181 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_getAllSectionRowsSectionOperators(t *testing.T) {
+	baseTemplate := "[Host]\nhttp://localhost/api\n[Headers]\nAuthorization: Bearer 1\nAccept: */*\n[Query]\npage=1\napi_key=2\n[BackendOptions]\n-sS\n-H 'X-Trace: 1'\n-H 'X-Debug: 1'\n--max-time 3"
+
+	tests := map[string]struct {
+		template               string
+		expectedHost           string
+		expectedHeaders        []string
+		expectedQuery          []string
+		expectedBackendOptions [][]string
+	}{
+		"Appends by default": {
+			template:               "[Host]\n/users\n[Headers]\nX-Id: 1\n[Query]\nsize=2\n[BackendOptions]\n-v",
+			expectedHost:           "http://localhost/api/users",
+			expectedHeaders:        []string{"Authorization: Bearer 1", "Accept: */*", "X-Id: 1"},
+			expectedQuery:          []string{"page=1", "api_key=2", "size=2"},
+			expectedBackendOptions: [][]string{{"-sS"}, {"-H", "X-Trace: 1"}, {"-H", "X-Debug: 1"}, {"--max-time", "3"}, {"-v"}},
+		},
+		"Replaces": {
+			template:               "[Host!]\nhttp://example.com\n[HEADERS!]\nX-Id: 1\n[Query!]\nsize=2\n[BackendOptions!]\n-v",
+			expectedHost:           "http://example.com",
+			expectedHeaders:        []string{"X-Id: 1"},
+			expectedQuery:          []string{"size=2"},
+			expectedBackendOptions: [][]string{{"-v"}},
+		},
+		"Removes": {
+			template:               "[Headers]\n-authorization\nAccept: application/json\n[Query]\n- api_key\n[BackendOptions]\n- -H 'X-Debug: 1'\n- --max-time",
+			expectedHost:           "http://localhost/api",
+			expectedHeaders:        []string{"Accept: */*", "Accept: application/json"},
+			expectedQuery:          []string{"page=1"},
+			expectedBackendOptions: [][]string{{"-sS"}, {"-H", "X-Trace: 1"}},
+		},
+	}
+
+	for name, test := range tests {
+		sectionedTemplates := []*sectionedTemplate{newSectionedTemplate(baseTemplate, ""), newSectionedTemplate(test.template, "")}
+
+		allSectionRows, fatals := getAllSectionRows(sectionedTemplates, data.NewConfig())
+		if len(fatals) > 0 {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, fatals)
+			continue
+		}
+
+		if allSectionRows.host != test.expectedHost ||
+			!reflect.DeepEqual(allSectionRows.headers, test.expectedHeaders) ||
+			!reflect.DeepEqual(allSectionRows.query, test.expectedQuery) ||
+			!reflect.DeepEqual(allSectionRows.backendOptions, test.expectedBackendOptions) {
+			t.Errorf("Test: %s. Unexpected rows: %s %v %v %v", name, allSectionRows.host, allSectionRows.headers, allSectionRows.query, allSectionRows.backendOptions)
+		}
+	}
+}
+
+func Test_getAllSectionRowsRemovesAuth(t *testing.T) {
+	tests := map[string]struct {
+		baseTemplate string
+		template     string
+	}{
+		"Authorization header": {
+			baseTemplate: "[Auth]\nbearer 1",
+			template:     "[Headers]\n-Authorization",
+		},
+		"Api key header": {
+			baseTemplate: "[Auth]\napikey header X-Api-Key 1",
+			template:     "[Headers]\n-x-api-key",
+		},
+		"Api key query parameter": {
+			baseTemplate: "[Auth]\napikey query api_key 1",
+			template:     "[Query]\n-api_key",
+		},
+	}
+
+	for name, test := range tests {
+		sectionedTemplates := []*sectionedTemplate{newSectionedTemplate(test.baseTemplate, ""), newSectionedTemplate(test.template, "")}
+
+		allSectionRows, fatals := getAllSectionRows(sectionedTemplates, data.NewConfig())
+		if len(fatals) > 0 || allSectionRows.auth != nil {
+			t.Errorf("Test: %s. Expected the auth to be removed, got: %v %v", name, allSectionRows.auth, fatals)
+		}
+	}
+}
+
+func Test_getBackendInputApiKeyHeader(t *testing.T) {
+	sectionedTemplates := []*sectionedTemplate{
+		newSectionedTemplate("[Host]\nhttp://localhost\n[Auth]\napikey header X-Api-Key 1\n[Backend]\ncurl", ""),
+		newSectionedTemplate("[Headers]\nAccept: */*\nx-api-key: 2", ""),
+	}
+
+	allSectionRows, fatals := getAllSectionRows(sectionedTemplates, data.NewConfig())
+	if len(fatals) > 0 {
+		t.Fatalf("Unexpected fatals: %v", fatals)
+	}
+
+	backendInput, fatals := getBackendInput(allSectionRows, data.NewConfig())
+	if len(fatals) > 0 {
+		t.Fatalf("Unexpected fatals: %v", fatals)
+	}
+
+	// An explicit header overrides the api key, same as any inherited value
+	if expectedHeaders := []string{"Accept: */*", "x-api-key: 2"}; !reflect.DeepEqual(backendInput.Headers, expectedHeaders) {
+		t.Errorf("Unexpected headers: %v", backendInput.Headers)
+	}
+}
+
+func Test_getBackendInputFormDropsInheritedContentType(t *testing.T) {
+	baseTemplate := "[Host]\nhttp://localhost\n[Headers]\nContent-Type: application/json\nAccept: */*\n[Backend]\ncurl"
+
+	tests := map[string]struct {
+		template        string
+		expectedHeaders []string
+	}{
+		"Form": {
+			template:        "[Form]\nname=ain",
+			expectedHeaders: []string{"Accept: */*", "Content-Type: application/x-www-form-urlencoded"},
+		},
+		"Form with its own Content-Type": {
+			template:        "[Headers]\nContent-Type: application/x-www-form-urlencoded; charset=utf-8\n[Form]\nname=ain",
+			expectedHeaders: []string{"Accept: */*", "Content-Type: application/x-www-form-urlencoded; charset=utf-8"},
+		},
+		"Multipart": {
+			template:        "[Multipart]\nname=ain",
+			expectedHeaders: []string{"Accept: */*"},
+		},
+	}
+
+	for name, test := range tests {
+		sectionedTemplates := []*sectionedTemplate{newSectionedTemplate(baseTemplate, ""), newSectionedTemplate(test.template, "")}
+
+		allSectionRows, fatals := getAllSectionRows(sectionedTemplates, data.NewConfig())
+		if len(fatals) > 0 {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, fatals)
+			continue
+		}
+
+		backendInput, fatals := getBackendInput(allSectionRows, data.NewConfig())
+		if len(fatals) > 0 {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, fatals)
+			continue
+		}
+
+		if !reflect.DeepEqual(backendInput.Headers, test.expectedHeaders) {
+			t.Errorf("Test: %s. Unexpected headers: %v", name, backendInput.Headers)
+		}
+	}
+}
+
+func Test_getAllSectionRowsSectionOperatorsBadCases(t *testing.T) {
+	tests := map[string]struct {
+		template             string
+		expectedFatalMessage string
+	}{
+		"Header with value": {
+			template:             "[Headers]\n-Authorization: Bearer 1",
+			expectedFatalMessage: "Remove a header by name only, e g -Authorization on line 2",
+		},
+		"Query with value": {
+			template:             "[Query]\n-page=1",
+			expectedFatalMessage: "Remove a query parameter by key only, e g -page on line 2",
+		},
+		"Both [Headers] and [Headers!]": {
+			template:             "[Headers]\nX-Id: 1\n[Headers!]\nX-Id: 2",
+			expectedFatalMessage: "Section [headers] on line 1 redeclared on line 3",
+		},
+	}
+
+	for name, test := range tests {
+		_, fatals := getAllSectionRows([]*sectionedTemplate{newSectionedTemplate(test.template, "")}, data.NewConfig())
+
+		if len(fatals) != 1 || !strings.Contains(fatals[0], test.expectedFatalMessage) {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, fatals)
+		}
+	}
+}
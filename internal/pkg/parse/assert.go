@@ -0,0 +1,201 @@
+package parse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/jonaslu/ain/internal/pkg/utils"
+)
+
+const (
+	equalsOperator         = "=="
+	notEqualsOperator      = "!="
+	lessThanOperator       = "<"
+	lessOrEqualOperator    = "<="
+	greaterThanOperator    = ">"
+	greaterOrEqualOperator = ">="
+	containsOperator       = "contains"
+	matchesOperator        = "matches"
+	existsOperator         = "exists"
+	notExistsOperator      = "!exists"
+)
+
+var assertOperators = []string{
+	equalsOperator,
+	notEqualsOperator,
+	lessThanOperator,
+	lessOrEqualOperator,
+	greaterThanOperator,
+	greaterOrEqualOperator,
+	containsOperator,
+	matchesOperator,
+	existsOperator,
+	notExistsOperator,
+}
+
+const maxAssertedValueLength = 60
+
+func isValidAssertOperator(operator string) bool {
+	for _, assertOperator := range assertOperators {
+		if operator == assertOperator {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *sectionedTemplate) getAssertions() []data.Assertion {
+	var assertions []data.Assertion
+
+	for _, assertSourceMarker := range *s.getNamedSection(assertSection) {
+		sourceLineIndex := assertSourceMarker.sourceLineIndex
+
+		tokens, err := utils.TokenizeLine(assertSourceMarker.lineContents)
+		if err != nil {
+			s.setFatalMessage(fmt.Sprintf("Could not parse assertion %s", err.Error()), sourceLineIndex)
+			continue
+		}
+
+		selector, rest, err := parseResponseSelector(tokens)
+		if err != nil {
+			s.setFatalMessage(err.Error(), sourceLineIndex)
+			continue
+		}
+
+		if len(rest) == 0 {
+			s.setFatalMessage("Missing operator in assertion", sourceLineIndex)
+			continue
+		}
+
+		operator := strings.ToLower(rest[0])
+		if !isValidAssertOperator(operator) {
+			s.setFatalMessage(fmt.Sprintf("Unknown assertion operator: %s, expected one of %s", rest[0], strings.Join(assertOperators, " ")), sourceLineIndex)
+			continue
+		}
+
+		expected := strings.Join(rest[1:], " ")
+
+		if operator == existsOperator || operator == notExistsOperator {
+			if expected != "" {
+				s.setFatalMessage(fmt.Sprintf("Operator %s takes no value", operator), sourceLineIndex)
+				continue
+			}
+		} else if expected == "" {
+			s.setFatalMessage(fmt.Sprintf("Missing value for operator %s", operator), sourceLineIndex)
+			continue
+		}
+
+		if operator == matchesOperator {
+			if _, err := regexp.Compile(expected); err != nil {
+				s.setFatalMessage(fmt.Sprintf("Invalid regular expression: %s", err.Error()), sourceLineIndex)
+				continue
+			}
+		}
+
+		assertions = append(assertions, data.Assertion{
+			Selector: selector,
+			Operator: operator,
+			Expected: expected,
+			Filename: s.filename,
+			FormatFatal: func(msg string) string {
+				return s.formatFatalMessage(msg, sourceLineIndex)
+			},
+		})
+	}
+
+	return assertions
+}
+
+func compareNumbers(actual, operator, expected string) (bool, string) {
+	actualNumber, err := strconv.ParseFloat(actual, 64)
+	if err != nil {
+		return false, fmt.Sprintf("%s is not a number", actual)
+	}
+
+	expectedNumber, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false, fmt.Sprintf("%s is not a number", expected)
+	}
+
+	switch operator {
+	case lessThanOperator:
+		return actualNumber < expectedNumber, ""
+	case lessOrEqualOperator:
+		return actualNumber <= expectedNumber, ""
+	case greaterThanOperator:
+		return actualNumber > expectedNumber, ""
+	}
+
+	return actualNumber >= expectedNumber, ""
+}
+
+// Returns an empty string if the assertion holds
+func checkAssertion(assertion data.Assertion, response *data.Response) string {
+	selectorDescription := describeResponseSelector(assertion.Selector)
+
+	actual, found, err := selectResponseValue(assertion.Selector, response)
+	if err != nil {
+		return fmt.Sprintf("Cannot check %s: %s", selectorDescription, err.Error())
+	}
+
+	switch assertion.Operator {
+	case existsOperator:
+		if !found {
+			return fmt.Sprintf("Expected %s to exist", selectorDescription)
+		}
+
+		return ""
+
+	case notExistsOperator:
+		if found {
+			return fmt.Sprintf("Expected %s not to exist, got: %s", selectorDescription, utils.Ellipsize(0, maxAssertedValueLength, actual))
+		}
+
+		return ""
+	}
+
+	if !found {
+		return fmt.Sprintf("Expected %s %s %s, but %s was not found", selectorDescription, assertion.Operator, assertion.Expected, selectorDescription)
+	}
+
+	var holds bool
+	switch assertion.Operator {
+	case equalsOperator:
+		holds = actual == assertion.Expected
+	case notEqualsOperator:
+		holds = actual != assertion.Expected
+	case containsOperator:
+		holds = strings.Contains(actual, assertion.Expected)
+	case matchesOperator:
+		holds = regexp.MustCompile(assertion.Expected).MatchString(actual)
+	default:
+		var notANumber string
+		if holds, notANumber = compareNumbers(actual, assertion.Operator, assertion.Expected); notANumber != "" {
+			return fmt.Sprintf("Cannot compare %s: %s", selectorDescription, notANumber)
+		}
+	}
+
+	if !holds {
+		return fmt.Sprintf("Expected %s %s %s, got: %s", selectorDescription, assertion.Operator, assertion.Expected, utils.Ellipsize(0, maxAssertedValueLength, actual))
+	}
+
+	return ""
+}
+
+// CheckAssertions returns a report of all failed assertions
+// grouped by template file or an empty string if all holds
+func CheckAssertions(assertions []data.Assertion, response *data.Response) string {
+	failures := responseFailures{}
+
+	for _, assertion := range assertions {
+		if failure := checkAssertion(assertion, response); failure != "" {
+			failures.add(assertion.Filename, assertion.FormatFatal(failure))
+		}
+	}
+
+	return failures.getReport("Failed assertion")
+}
@@ -0,0 +1,87 @@
+package parse
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_checkAssertion(t *testing.T) {
+	response := &data.Response{
+		StatusCode: 201,
+		Headers:    http.Header{"Content-Type": []string{"application/json"}},
+		Body:       `{"id": 42, "name": "goat", "tags": ["a"]}`,
+	}
+
+	tests := map[string]struct {
+		assertion       string
+		expectedFailure string
+	}{
+		"Status equals": {
+			assertion: "status == 201",
+		},
+		"Status not equals": {
+			assertion:       "status == 200",
+			expectedFailure: "Expected status == 200, got: 201",
+		},
+		"Status range": {
+			assertion: "status < 300",
+		},
+		"Header contains case insensitive name": {
+			assertion: "header content-type contains json",
+		},
+		"Missing header": {
+			assertion:       "header X-Missing == 1",
+			expectedFailure: "Expected header X-Missing == 1, but header X-Missing was not found",
+		},
+		"Header not exists": {
+			assertion: "header X-Missing !exists",
+		},
+		"Json path exists": {
+			assertion: "body $.id exists",
+		},
+		"Json path short form": {
+			assertion: "$.tags[0] == a",
+		},
+		"Json path matches": {
+			assertion:       `$.name matches "^sheep"`,
+			expectedFailure: "Expected body $.name matches ^sheep, got: goat",
+		},
+		"Json path not a number": {
+			assertion:       "$.name > 1",
+			expectedFailure: "Cannot compare body $.name: goat is not a number",
+		},
+		"Whole body": {
+			assertion: "body contains goat",
+		},
+	}
+
+	for name, test := range tests {
+		s := newSectionedTemplate("[Assert]\n"+test.assertion, "")
+		s.setCapturedSections(assertSection)
+
+		assertions := s.getAssertions()
+		if s.hasFatalMessages() {
+			t.Errorf("Test: %s. Got unexpected fatals, %s ", name, s.getFatalMessages())
+			continue
+		}
+
+		if failure := checkAssertion(assertions[0], response); failure != test.expectedFailure {
+			t.Errorf("Test: %s. Expected failure %q, got: %q", name, test.expectedFailure, failure)
+		}
+	}
+}
+
+func Test_CheckAssertionsBodyNotJson(t *testing.T) {
+	s := newSectionedTemplate("[Assert]\n$.id exists", "file.ain")
+	s.setCapturedSections(assertSection)
+
+	report := CheckAssertions(s.getAssertions(), &data.Response{Body: "not json"})
+
+	expectedReport := "Failed assertion in file: file.ain\nCannot check body $.id: Response body is not valid JSON"
+	if !strings.HasPrefix(report, expectedReport) {
+		t.Errorf("Unexpected report: %s", report)
+	}
+}
@@ -0,0 +1,348 @@
+package parse
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+const apiKeyAuthScheme = "apikey"
+
+const (
+	apiKeyInHeader = "header"
+	apiKeyInQuery  = "query"
+)
+
+var authSchemes = []string{
+	data.BasicAuthScheme,
+	data.BearerAuthScheme,
+	data.DigestAuthScheme,
+	apiKeyAuthScheme,
+	data.OAuth2AuthScheme,
+	data.AwsSigV4AuthScheme,
+}
+
+const authParameterDelim = "="
+
+const (
+	tokenUrlAuthParameter     = "token_url"
+	clientIdAuthParameter     = "client_id"
+	clientSecretAuthParameter = "client_secret"
+	scopeAuthParameter        = "scope"
+	refreshTokenAuthParameter = "refresh_token"
+	clientAuthAuthParameter   = "client_auth"
+	regionAuthParameter       = "region"
+	serviceAuthParameter      = "service"
+)
+
+// Schemes taking name = value parameters on
+// the lines below the scheme, and which ones
+var authSchemeParameters = map[string][]string{
+	data.OAuth2AuthScheme: {
+		tokenUrlAuthParameter,
+		clientIdAuthParameter,
+		clientSecretAuthParameter,
+		scopeAuthParameter,
+		refreshTokenAuthParameter,
+		clientAuthAuthParameter,
+	},
+	data.AwsSigV4AuthScheme: {
+		regionAuthParameter,
+		serviceAuthParameter,
+	},
+}
+
+const (
+	clientAuthBasic = "basic"
+	clientAuthBody  = "body"
+)
+
+// API keys are plain headers or query parameters
+// so they're added before the backend sees them
+type apiKey struct {
+	in    string
+	name  string
+	value string
+}
+
+type auth struct {
+	auth   data.Auth
+	apiKey *apiKey
+
+	filename    string
+	formatFatal func(msg string) string
+}
+
+type authParameter struct {
+	value           string
+	sourceLineIndex int
+}
+
+// Splits off the first word, the rest is kept
+// as is since passwords and tokens can contain spaces
+func splitFirstWord(line string) (string, string) {
+	line = strings.TrimSpace(line)
+
+	idx := strings.IndexAny(line, " \t")
+	if idx == -1 {
+		return line, ""
+	}
+
+	return line[:idx], strings.TrimSpace(line[idx:])
+}
+
+func isValidAuthParameter(scheme, name string) bool {
+	for _, validName := range authSchemeParameters[scheme] {
+		if name == validName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isValidAuthScheme(scheme string) bool {
+	for _, validScheme := range authSchemes {
+		if scheme == validScheme {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *sectionedTemplate) getAuthParameters(scheme string, parameterSourceMarkers []sourceMarker) (map[string]authParameter, bool) {
+	parameters := map[string]authParameter{}
+	ok := true
+
+	for _, parameterSourceMarker := range parameterSourceMarkers {
+		sourceLineIndex := parameterSourceMarker.sourceLineIndex
+
+		if _, takesParameters := authSchemeParameters[scheme]; !takesParameters {
+			s.setFatalMessage(fmt.Sprintf("Auth scheme %s takes no parameters, found several lines under [Auth]", scheme), sourceLineIndex)
+			ok = false
+			continue
+		}
+
+		name, value, found := strings.Cut(parameterSourceMarker.lineContents, authParameterDelim)
+		if !found {
+			s.setFatalMessage("Missing = in auth parameter, expected name = value", sourceLineIndex)
+			ok = false
+			continue
+		}
+
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+
+		if !isValidAuthParameter(scheme, name) {
+			s.setFatalMessage(fmt.Sprintf("Unknown %s parameter: %s, expected one of %s", scheme, name, strings.Join(authSchemeParameters[scheme], " ")), sourceLineIndex)
+			ok = false
+			continue
+		}
+
+		if previous, exists := parameters[name]; exists {
+			s.setFatalMessage(fmt.Sprintf("Auth parameter %s on line %d redeclared", name, s.expandedTemplateLines[previous.sourceLineIndex].sourceLineIndex+1), sourceLineIndex)
+			ok = false
+			continue
+		}
+
+		parameters[name] = authParameter{value: value, sourceLineIndex: sourceLineIndex}
+	}
+
+	return parameters, ok
+}
+
+func (s *sectionedTemplate) getOAuth2(grantType string, parameters map[string]authParameter, sourceLineIndex int) *data.OAuth2 {
+	requiredParameters := []string{tokenUrlAuthParameter, clientIdAuthParameter}
+
+	switch grantType {
+	case data.ClientCredentialsGrant:
+		requiredParameters = append(requiredParameters, clientSecretAuthParameter)
+	case data.RefreshTokenGrant:
+		requiredParameters = append(requiredParameters, refreshTokenAuthParameter)
+	default:
+		s.setFatalMessage(fmt.Sprintf("Unknown oauth2 grant type: %s, expected %s or %s", grantType, data.ClientCredentialsGrant, data.RefreshTokenGrant), sourceLineIndex)
+		return nil
+	}
+
+	missingParameters := []string{}
+	for _, requiredParameter := range requiredParameters {
+		if parameters[requiredParameter].value == "" {
+			missingParameters = append(missingParameters, requiredParameter)
+		}
+	}
+
+	if len(missingParameters) > 0 {
+		sort.Strings(missingParameters)
+		s.setFatalMessage(fmt.Sprintf("Missing %s for oauth2 %s, expected name = value lines below the scheme", strings.Join(missingParameters, ", "), grantType), sourceLineIndex)
+		return nil
+	}
+
+	clientAuth := parameters[clientAuthAuthParameter]
+	if clientAuth.value != "" && clientAuth.value != clientAuthBasic && clientAuth.value != clientAuthBody {
+		s.setFatalMessage(fmt.Sprintf("Unknown client_auth: %s, expected %s or %s", clientAuth.value, clientAuthBasic, clientAuthBody), clientAuth.sourceLineIndex)
+		return nil
+	}
+
+	return &data.OAuth2{
+		GrantType:        grantType,
+		TokenUrl:         parameters[tokenUrlAuthParameter].value,
+		ClientId:         parameters[clientIdAuthParameter].value,
+		ClientSecret:     parameters[clientSecretAuthParameter].value,
+		Scope:            parameters[scopeAuthParameter].value,
+		RefreshToken:     parameters[refreshTokenAuthParameter].value,
+		ClientAuthInBody: clientAuth.value == clientAuthBody,
+	}
+}
+
+// Credentials are read from the same environment
+// variables as the aws cli so they're never in a template
+func (s *sectionedTemplate) getAwsSigV4(parameters map[string]authParameter, sourceLineIndex int) *data.AwsSigV4 {
+	region := parameters[regionAuthParameter].value
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	awsSigV4 := &data.AwsSigV4{
+		Region:          region,
+		Service:         parameters[serviceAuthParameter].value,
+		AccessKeyId:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	missing := []string{}
+	if awsSigV4.Service == "" {
+		missing = append(missing, serviceAuthParameter)
+	}
+
+	if awsSigV4.Region == "" {
+		missing = append(missing, regionAuthParameter+" (or AWS_REGION)")
+	}
+
+	if awsSigV4.AccessKeyId == "" {
+		missing = append(missing, "AWS_ACCESS_KEY_ID")
+	}
+
+	if awsSigV4.SecretAccessKey == "" {
+		missing = append(missing, "AWS_SECRET_ACCESS_KEY")
+	}
+
+	if len(missing) > 0 {
+		s.setFatalMessage(fmt.Sprintf("Missing %s for aws-sigv4", strings.Join(missing, ", ")), sourceLineIndex)
+		return nil
+	}
+
+	return awsSigV4
+}
+
+// An inherited [Auth] is sent as the Authorization header, or the
+// api key header or query parameter, so removing those removes it
+func (a *auth) isRemovedBy(removedHeaderNames, removedQueryKeys []string) bool {
+	if a.apiKey == nil {
+		return containsFold(removedHeaderNames, "Authorization")
+	}
+
+	if a.apiKey.in == apiKeyInHeader {
+		return containsFold(removedHeaderNames, a.apiKey.name)
+	}
+
+	for _, removedQueryKey := range removedQueryKeys {
+		if removedQueryKey == a.apiKey.name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *sectionedTemplate) getAuth() *auth {
+	authSourceMarkers := *s.getNamedSection(authSection)
+	if len(authSourceMarkers) == 0 {
+		return nil
+	}
+
+	sourceLineIndex := authSourceMarkers[0].sourceLineIndex
+	scheme, credentials := splitFirstWord(authSourceMarkers[0].lineContents)
+	scheme = strings.ToLower(scheme)
+
+	templateAuth := auth{
+		auth:     data.Auth{Scheme: scheme},
+		filename: s.filename,
+		formatFatal: func(msg string) string {
+			return s.formatFatalMessage(msg, sourceLineIndex)
+		},
+	}
+
+	if !isValidAuthScheme(scheme) {
+		s.setFatalMessage(fmt.Sprintf("Unknown auth scheme: %s, expected one of %s", scheme, strings.Join(authSchemes, " ")), sourceLineIndex)
+		return nil
+	}
+
+	parameters, ok := s.getAuthParameters(scheme, authSourceMarkers[1:])
+	if !ok {
+		return nil
+	}
+
+	switch scheme {
+	case data.BasicAuthScheme, data.DigestAuthScheme:
+		user, password, found := strings.Cut(credentials, ":")
+		if !found {
+			s.setFatalMessage(fmt.Sprintf("Missing : in %s credentials, expected %s user:password", scheme, scheme), sourceLineIndex)
+			return nil
+		}
+
+		templateAuth.auth.User = user
+		templateAuth.auth.Password = password
+
+	case data.BearerAuthScheme:
+		if credentials == "" {
+			s.setFatalMessage("Missing token, expected bearer <token>", sourceLineIndex)
+			return nil
+		}
+
+		templateAuth.auth.Token = credentials
+
+	case apiKeyAuthScheme:
+		in, rest := splitFirstWord(credentials)
+		name, value := splitFirstWord(rest)
+		in = strings.ToLower(in)
+
+		if (in != apiKeyInHeader && in != apiKeyInQuery) || name == "" || value == "" {
+			s.setFatalMessage("Malformed api key, expected apikey header|query <name> <value>", sourceLineIndex)
+			return nil
+		}
+
+		templateAuth.apiKey = &apiKey{in: in, name: name, value: value}
+
+	case data.OAuth2AuthScheme:
+		oauth2 := s.getOAuth2(strings.ToLower(credentials), parameters, sourceLineIndex)
+		if oauth2 == nil {
+			return nil
+		}
+
+		templateAuth.auth.OAuth2 = oauth2
+
+	case data.AwsSigV4AuthScheme:
+		if credentials != "" {
+			s.setFatalMessage("Auth scheme aws-sigv4 takes no credentials, they are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY", sourceLineIndex)
+			return nil
+		}
+
+		awsSigV4 := s.getAwsSigV4(parameters, sourceLineIndex)
+		if awsSigV4 == nil {
+			return nil
+		}
+
+		templateAuth.auth.AwsSigV4 = awsSigV4
+	}
+
+	return &templateAuth
+}
@@ -0,0 +1,141 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_getAuth(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "eu-north-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	tests := map[string]struct {
+		input          string
+		expectedAuth   data.Auth
+		expectedApiKey *apiKey
+	}{
+		"Basic with colon in password": {
+			input:        "[Auth]\nBASIC user:pass:word",
+			expectedAuth: data.Auth{Scheme: data.BasicAuthScheme, User: "user", Password: "pass:word"},
+		},
+		"Bearer with spaces kept": {
+			input:        "[Auth]\nbearer  some token",
+			expectedAuth: data.Auth{Scheme: data.BearerAuthScheme, Token: "some token"},
+		},
+		"Oauth2 with parameters": {
+			input: "[Auth]\noauth2 Client_Credentials\ntoken_url = http://localhost/token?a=b\nClient_Id=id\nclient_secret = secret\nclient_auth = body",
+			expectedAuth: data.Auth{Scheme: data.OAuth2AuthScheme, OAuth2: &data.OAuth2{
+				GrantType:        data.ClientCredentialsGrant,
+				TokenUrl:         "http://localhost/token?a=b",
+				ClientId:         "id",
+				ClientSecret:     "secret",
+				ClientAuthInBody: true,
+			}},
+		},
+		"Aws-sigv4 with region from the environment": {
+			input: "[Auth]\naws-sigv4\nservice = s3",
+			expectedAuth: data.Auth{Scheme: data.AwsSigV4AuthScheme, AwsSigV4: &data.AwsSigV4{
+				Region:          "eu-north-1",
+				Service:         "s3",
+				AccessKeyId:     "key",
+				SecretAccessKey: "secret",
+			}},
+		},
+		"Api key in query": {
+			input:          "[Auth]\napikey Query api_key 1 2",
+			expectedAuth:   data.Auth{Scheme: apiKeyAuthScheme},
+			expectedApiKey: &apiKey{in: apiKeyInQuery, name: "api_key", value: "1 2"},
+		},
+	}
+
+	for name, test := range tests {
+		s := newSectionedTemplate(test.input, "")
+		s.setCapturedSections(authSection)
+
+		templateAuth := s.getAuth()
+		if s.hasFatalMessages() {
+			t.Errorf("Test: %s. Unexpected fatals: %s", name, s.getFatalMessages())
+			continue
+		}
+
+		if !reflect.DeepEqual(templateAuth.auth, test.expectedAuth) || !reflect.DeepEqual(templateAuth.apiKey, test.expectedApiKey) {
+			t.Errorf("Test: %s. Unexpected auth: %+v %+v", name, templateAuth.auth, templateAuth.apiKey)
+		}
+	}
+}
+
+func Test_getAuthBadCases(t *testing.T) {
+	for _, awsEnvVar := range []string{"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"} {
+		t.Setenv(awsEnvVar, "")
+	}
+
+	tests := map[string]struct {
+		input                string
+		expectedFatalMessage string
+	}{
+		"Several lines": {
+			input:                "[Auth]\nbearer 1\nbearer 2",
+			expectedFatalMessage: "Auth scheme bearer takes no parameters, found several lines under [Auth] on line 3",
+		},
+		"Unknown grant type": {
+			input:                "[Auth]\noauth2 password",
+			expectedFatalMessage: "Unknown oauth2 grant type: password, expected client_credentials or refresh_token on line 2",
+		},
+		"Missing oauth2 parameters": {
+			input:                "[Auth]\noauth2 client_credentials\nclient_id = id",
+			expectedFatalMessage: "Missing client_secret, token_url for oauth2 client_credentials, expected name = value lines below the scheme on line 2",
+		},
+		"Unknown oauth2 parameter": {
+			input:                "[Auth]\noauth2 client_credentials\naudience = api",
+			expectedFatalMessage: "Unknown oauth2 parameter: audience, expected one of token_url client_id client_secret scope refresh_token client_auth on line 3",
+		},
+		"Redeclared oauth2 parameter": {
+			input:                "[Auth]\noauth2 refresh_token\nscope = a\nscope = b",
+			expectedFatalMessage: "Auth parameter scope on line 3 redeclared on line 4",
+		},
+		"Missing aws-sigv4 parameters and credentials": {
+			input:                "[Auth]\naws-sigv4",
+			expectedFatalMessage: "Missing service, region (or AWS_REGION), AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY for aws-sigv4 on line 2",
+		},
+		"Credentials for aws-sigv4 in the template": {
+			input:                "[Auth]\naws-sigv4 key:secret",
+			expectedFatalMessage: "Auth scheme aws-sigv4 takes no credentials, they are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY on line 2",
+		},
+		"Unknown client auth": {
+			input:                "[Auth]\noauth2 client_credentials\ntoken_url=http://localhost\nclient_id=id\nclient_secret=secret\nclient_auth=header",
+			expectedFatalMessage: "Unknown client_auth: header, expected basic or body on line 6",
+		},
+		"Unknown scheme": {
+			input:                "[Auth]\nntlm user:pass",
+			expectedFatalMessage: "Unknown auth scheme: ntlm, expected one of basic bearer digest apikey oauth2 aws-sigv4 on line 2",
+		},
+		"Missing colon": {
+			input:                "[Auth]\ndigest user",
+			expectedFatalMessage: "Missing : in digest credentials, expected digest user:password on line 2",
+		},
+		"Missing token": {
+			input:                "[Auth]\nbearer",
+			expectedFatalMessage: "Missing token, expected bearer <token> on line 2",
+		},
+		"Api key in cookie": {
+			input:                "[Auth]\napikey cookie key 1",
+			expectedFatalMessage: "Malformed api key, expected apikey header|query <name> <value> on line 2",
+		},
+	}
+
+	for name, test := range tests {
+		s := newSectionedTemplate(test.input, "")
+		s.setCapturedSections(authSection)
+		s.getAuth()
+
+		if len(s.fatals) != 1 || !strings.HasPrefix(s.fatals[0], test.expectedFatalMessage) {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, s.fatals)
+		}
+	}
+}
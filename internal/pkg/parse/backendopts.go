@@ -2,23 +2,78 @@ package parse
 
 import (
 	"fmt"
+	"strings"
+	"unicode"
 
 	"github.com/jonaslu/ain/internal/pkg/utils"
 )
 
-func (s *sectionedTemplate) getBackendOptions() [][]string {
+// Backend options start with a dash themselves so
+// removing one is a dash and a space, e g - --insecure
+func isRemovedBackendOption(line string) bool {
+	return len(line) > 1 && strings.HasPrefix(line, removeLinePrefix) && unicode.IsSpace(rune(line[1]))
+}
+
+// Returns the backend options and the inherited backend options to remove
+func (s *sectionedTemplate) getBackendOptions() ([][]string, [][]string) {
 	var backendOptions [][]string
+	var removedBackendOptions [][]string
 
 	for _, backedOptionSourceMarker := range *s.getNamedSection(backendOptionsSection) {
-		tokenizedBackendOpts, err := utils.TokenizeLine(backedOptionSourceMarker.lineContents)
+		backendOptionsLine := backedOptionSourceMarker.lineContents
+
+		removed := isRemovedBackendOption(backendOptionsLine)
+		if removed {
+			backendOptionsLine = strings.TrimPrefix(backendOptionsLine, removeLinePrefix)
+		}
+
+		tokenizedBackendOpts, err := utils.TokenizeLine(backendOptionsLine)
 		if err != nil {
 			// !! TODO !! Can parse all messages don't have to return
 			s.setFatalMessage(fmt.Sprintf("Could not parse backend-option %s", err.Error()), backedOptionSourceMarker.sourceLineIndex)
-			return backendOptions
+			return backendOptions, removedBackendOptions
+		}
+
+		if removed {
+			removedBackendOptions = append(removedBackendOptions, tokenizedBackendOpts)
+			continue
 		}
 
 		backendOptions = append(backendOptions, tokenizedBackendOpts)
 	}
 
-	return backendOptions
+	return backendOptions, removedBackendOptions
+}
+
+// A single option removes all lines starting with it (e g -H),
+// several remove the lines with exactly those (e g -H 'Accept: */*')
+func isBackendOptionRemoved(backendOptionLine []string, removedBackendOption []string) bool {
+	if len(removedBackendOption) == 0 || len(backendOptionLine) == 0 {
+		return false
+	}
+
+	if len(removedBackendOption) == 1 {
+		return backendOptionLine[0] == removedBackendOption[0]
+	}
+
+	return strings.Join(backendOptionLine, "\x00") == strings.Join(removedBackendOption, "\x00")
+}
+
+func removeBackendOptions(backendOptions, removedBackendOptions [][]string) [][]string {
+	keptBackendOptions := [][]string{}
+
+	for _, backendOptionLine := range backendOptions {
+		removed := false
+		for _, removedBackendOption := range removedBackendOptions {
+			if isBackendOptionRemoved(backendOptionLine, removedBackendOption) {
+				removed = true
+			}
+		}
+
+		if !removed {
+			keptBackendOptions = append(keptBackendOptions, backendOptionLine)
+		}
+	}
+
+	return keptBackendOptions
 }
@@ -0,0 +1,105 @@
+package parse
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const expandBodyFileModifier = "expand"
+
+type bodyFile struct {
+	filename string
+	// Read as if the contents were in [Body]
+	expand bool
+}
+
+func (s *sectionedTemplate) getBodyFile() *bodyFile {
+	bodyFileSourceMarkers := *s.getNamedSection(bodyFileSection)
+	if len(bodyFileSourceMarkers) == 0 {
+		return nil
+	}
+
+	if len(bodyFileSourceMarkers) > 1 {
+		s.setFatalMessage("Found several lines under [BodyFile]", bodyFileSourceMarkers[0].sourceLineIndex)
+		return nil
+	}
+
+	bodyFileSourceMarker := bodyFileSourceMarkers[0]
+	templateBodyFile := bodyFile{filename: bodyFileSourceMarker.lineContents}
+
+	if modifier, filename := splitFirstWord(bodyFileSourceMarker.lineContents); strings.ToLower(modifier) == expandBodyFileModifier && filename != "" {
+		templateBodyFile.filename = filename
+		templateBodyFile.expand = true
+	}
+
+	templateBodyFile.filename = s.getTemplateRelativeFilename(templateBodyFile.filename)
+
+	if stat, err := os.Stat(templateBodyFile.filename); err != nil || stat.IsDir() {
+		s.setFatalMessage(fmt.Sprintf("Cannot find body file %s", templateBodyFile.filename), bodyFileSourceMarker.sourceLineIndex)
+		return nil
+	}
+
+	return &templateBodyFile
+}
+
+// The whole file is the [Body] section, no headings or comments
+// are looked for. Only variables and executables are replaced.
+func newBodyFileSectionedTemplate(rawBody, filename string) *sectionedTemplate {
+	sectionedTemplate := newSectionedTemplate(rawBody, filename)
+	sectionedTemplate.isBodyFile = true
+
+	for i := range sectionedTemplate.expandedTemplateLines {
+		expandedTemplateLine := &sectionedTemplate.expandedTemplateLines[i]
+		rawLine := sectionedTemplate.rawTemplateLines[expandedTemplateLine.sourceLineIndex]
+
+		expandedTemplateLine.content = rawLine
+		expandedTemplateLine.fatalContent = rawLine
+		expandedTemplateLine.comment = ""
+	}
+
+	return sectionedTemplate
+}
+
+func (s *sectionedTemplate) splitTextOnComment(input string) (string, string) {
+	if s.isBodyFile {
+		return input, ""
+	}
+
+	return splitTextOnComment(input)
+}
+
+// An expanded body file is added to the chain right after the
+// template so variables and executables are replaced in it
+func (c *templateChain) addExpandedBodyFile(s *sectionedTemplate) {
+	// Already added to the chain fatals by addIncludes
+	if s.hasFatalMessages() {
+		return
+	}
+
+	if s.setCapturedSections(bodyFileSection); s.hasFatalMessages() {
+		c.fatals = append(c.fatals, s.getFatalMessages())
+		return
+	}
+
+	templateBodyFile := s.getBodyFile()
+	if s.hasFatalMessages() {
+		c.fatals = append(c.fatals, s.getFatalMessages())
+		return
+	}
+
+	if templateBodyFile == nil || !templateBodyFile.expand {
+		return
+	}
+
+	rawBody, err := os.ReadFile(templateBodyFile.filename)
+	if err != nil {
+		s.setFatalMessage(fmt.Sprintf("Cannot read body file %s: %s", templateBodyFile.filename, err.Error()), (*s.getNamedSection(bodyFileSection))[0].sourceLineIndex)
+		c.fatals = append(c.fatals, s.getFatalMessages())
+		return
+	}
+
+	bodyFileSectionedTemplate := newBodyFileSectionedTemplate(string(rawBody), templateBodyFile.filename)
+	c.sectionedTemplates = append(c.sectionedTemplates, bodyFileSectionedTemplate)
+	c.includedBy[bodyFileSectionedTemplate] = s.filename
+}
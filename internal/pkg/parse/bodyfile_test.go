@@ -0,0 +1,100 @@
+package parse
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_getAllSectionRowsBodyFile(t *testing.T) {
+	dir := writeIncludeTestFiles(t, map[string]string{
+		"payload.json": "\n{\n  \"colour\": \"#fff\", \"tag\": \"`#1\"\n}\n[Host]\n",
+		"image.png":    "\x89PNG\r\n",
+	})
+
+	payloadFilename := filepath.Join(dir, "payload.json")
+	imageFilename := filepath.Join(dir, "image.png")
+
+	files := writeIncludeTestFiles(t, map[string]string{
+		"base.template":         "[Body]\n{}",
+		"expand.ain":            "[BodyFile]\nEXPAND " + payloadFilename,
+		"raw.ain":               "[BodyFile]\n" + imageFilename,
+		"payloads/b.png":        "\x89PNG\r\n",
+		"requests/relative.ain": "[BodyFile]\n../payloads/b.png",
+	})
+
+	tests := map[string]struct {
+		filenames            []string
+		expectedBody         []string
+		expectedBodyFileName string
+	}{
+		"Expanded body file overwrites the [Body]": {
+			filenames:    []string{"base.template", "expand.ain"},
+			expectedBody: []string{"{", `  "colour": "#fff", "tag": "` + "`#1" + `"`, "}", "[Host]"},
+		},
+		"Body file overwrites the [Body]": {
+			filenames:            []string{"base.template", "raw.ain"},
+			expectedBodyFileName: imageFilename,
+		},
+		"Body file relative to the template": {
+			filenames:            []string{"requests/relative.ain"},
+			expectedBodyFileName: filepath.Join(files, "payloads", "b.png"),
+		},
+		"[Body] overwrites the body file": {
+			filenames:    []string{"raw.ain", "base.template"},
+			expectedBody: []string{"{}"},
+		},
+	}
+
+	for name, test := range tests {
+		filenames := []string{}
+		for _, filename := range test.filenames {
+			filenames = append(filenames, filepath.Join(files, filename))
+		}
+
+		sectionedTemplates, fatals, err := getAllSectionedTemplates(filenames)
+		if err != nil || len(fatals) > 0 {
+			t.Errorf("Test: %s. Unexpected error: %v %v", name, err, fatals)
+			continue
+		}
+
+		allSectionRows, fatals := getAllSectionRows(sectionedTemplates, data.NewConfig())
+		if len(fatals) > 0 {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, fatals)
+			continue
+		}
+
+		if !reflect.DeepEqual(allSectionRows.body, test.expectedBody) || allSectionRows.bodyFileName != test.expectedBodyFileName {
+			t.Errorf("Test: %s. Unexpected body: %q %s", name, allSectionRows.body, allSectionRows.bodyFileName)
+		}
+	}
+}
+
+func Test_getBodyFileBadCases(t *testing.T) {
+	tests := map[string]struct {
+		input                string
+		expectedFatalMessage string
+	}{
+		"Missing file": {
+			input:                "[BodyFile]\nexpand /does/not/exist.json",
+			expectedFatalMessage: "Cannot find body file /does/not/exist.json on line 2",
+		},
+		"Several lines": {
+			input:                "[BodyFile]\na.json\nb.json",
+			expectedFatalMessage: "Found several lines under [BodyFile] on line 2",
+		},
+	}
+
+	for name, test := range tests {
+		s := newSectionedTemplate(test.input, "")
+		s.setCapturedSections(bodyFileSection)
+		s.getBodyFile()
+
+		if len(s.fatals) != 1 || !strings.HasPrefix(s.fatals[0], test.expectedFatalMessage) {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, s.fatals)
+		}
+	}
+}
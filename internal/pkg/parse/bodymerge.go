@@ -0,0 +1,193 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Keeps the keys in the order they're written
+// so the merged body reads like the templates
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		keyJson, err := marshalJsonValue(key)
+		if err != nil {
+			return nil, err
+		}
+
+		valueJson, err := marshalJsonValue(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(keyJson)
+		buf.WriteString(":")
+		buf.Write(valueJson)
+	}
+
+	buf.WriteString("}")
+
+	return buf.Bytes(), nil
+}
+
+// Same as json.Marshal, but leaves <, > and & as is
+func marshalJsonValue(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func formatJsonValue(value interface{}) string {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	// Only holds values decoded by decodeJsonValue, they always encode
+	_ = encoder.Encode(value)
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func decodeJsonValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := &jsonObject{values: map[string]interface{}{}}
+
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			key := keyToken.(string)
+			value, err := decodeJsonValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, exists := object.values[key]; !exists {
+				object.keys = append(object.keys, key)
+			}
+
+			object.values[key] = value
+		}
+
+		_, err = decoder.Token()
+		return object, err
+
+	case json.Delim('['):
+		array := []interface{}{}
+
+		for decoder.More() {
+			value, err := decodeJsonValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			array = append(array, value)
+		}
+
+		_, err = decoder.Token()
+		return array, err
+	}
+
+	// A string, json.Number, bool or nil
+	return token, nil
+}
+
+// Objects are merged key by key, anything else in
+// override (including arrays and null) replaces base
+func mergeJsonValues(base, override interface{}) interface{} {
+	baseObject, baseIsObject := base.(*jsonObject)
+	overrideObject, overrideIsObject := override.(*jsonObject)
+
+	if !baseIsObject || !overrideIsObject {
+		return override
+	}
+
+	for _, key := range overrideObject.keys {
+		if _, exists := baseObject.values[key]; !exists {
+			baseObject.keys = append(baseObject.keys, key)
+		}
+
+		baseObject.values[key] = mergeJsonValues(baseObject.values[key], overrideObject.values[key])
+	}
+
+	return baseObject
+}
+
+// Sets a fatal pointing at the line where the JSON is invalid
+func (s *sectionedTemplate) decodeJsonLines(sourceMarkers []sourceMarker, fatalMsg string) (interface{}, bool) {
+	lines := []string{}
+	for _, sourceMarker := range sourceMarkers {
+		lines = append(lines, sourceMarker.lineContents)
+	}
+
+	text := strings.Join(lines, "\n")
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	jsonValue, err := decodeJsonValue(decoder)
+	if err == nil {
+		if _, err = decoder.Token(); err == io.EOF {
+			return jsonValue, true
+		}
+
+		if err == nil {
+			err = fmt.Errorf("unexpected data after the JSON value")
+		}
+	}
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = fmt.Errorf("unexpected end of JSON input")
+	}
+
+	// Point to the line where the decoder stopped
+	offset := int(decoder.InputOffset())
+	if offset > len(text) {
+		offset = len(text)
+	}
+
+	errorLine := strings.Count(text[:offset], "\n")
+	s.setFatalMessage(fmt.Sprintf("%s: %s", fatalMsg, err.Error()), sourceMarkers[errorLine].sourceLineIndex)
+
+	return nil, false
+}
+
+func (s *sectionedTemplate) getJsonBody() (interface{}, bool) {
+	bodySourceMarkers := *s.getNamedSection(bodySection)
+	if len(bodySourceMarkers) == 0 {
+		return nil, false
+	}
+
+	return s.decodeJsonLines(bodySourceMarkers, "Invalid JSON in [Body] with BodyMerge=json")
+}
@@ -0,0 +1,101 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_getAllSectionRowsBodyMerge(t *testing.T) {
+	tests := map[string]struct {
+		templates    []string
+		bodyMerge    string
+		expectedBody string
+	}{
+		"Objects are merged and keys kept in order": {
+			templates: []string{
+				"[Body]\n{\n  \"currency\": \"EUR\",\n  \"customer\": { \"country\": \"SE\", \"vip\": false },\n  \"tags\": [\"a\"]\n}",
+				"[Body]\n{ \"amount\": 10.50, \"customer\": { \"vip\": true }, \"tags\": [\"b\"], \"note\": \"<&>\" }",
+			},
+			bodyMerge:    data.BodyMergeJson,
+			expectedBody: "{\n  \"currency\": \"EUR\",\n  \"customer\": {\n    \"country\": \"SE\",\n    \"vip\": true\n  },\n  \"tags\": [\n    \"b\"\n  ],\n  \"amount\": 10.50,\n  \"note\": \"<&>\"\n}",
+		},
+		"Anything but an object replaces": {
+			templates:    []string{"[Body]\n{ \"a\": 1 }", "[Body]\n[1, 2]"},
+			bodyMerge:    data.BodyMergeJson,
+			expectedBody: "[\n  1,\n  2\n]",
+		},
+		"Templates without a [Body] are skipped": {
+			templates:    []string{"[Body]\n{ \"a\": 1 }", "[Host]\nlocalhost", "[Body]\n{ \"b\": null }"},
+			bodyMerge:    data.BodyMergeJson,
+			expectedBody: "{\n  \"a\": 1,\n  \"b\": null\n}",
+		},
+		"Overwritten without merge": {
+			templates:    []string{"[Body]\n{ \"a\": 1 }", "[Body]\n{ \"b\": 2 }"},
+			bodyMerge:    data.BodyMergeNone,
+			expectedBody: "{ \"b\": 2 }",
+		},
+	}
+
+	for name, test := range tests {
+		sectionedTemplates := []*sectionedTemplate{}
+		for _, template := range test.templates {
+			sectionedTemplates = append(sectionedTemplates, newSectionedTemplate(template, ""))
+		}
+
+		config := data.NewConfig()
+		config.BodyMerge = test.bodyMerge
+
+		allSectionRows, fatals := getAllSectionRows(sectionedTemplates, config)
+		if len(fatals) > 0 {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, fatals)
+			continue
+		}
+
+		if body := strings.Join(allSectionRows.body, "\n"); body != test.expectedBody {
+			t.Errorf("Test: %s. Unexpected body: %s", name, body)
+		}
+	}
+}
+
+func Test_getJsonBodyBadCases(t *testing.T) {
+	tests := map[string]struct {
+		input                string
+		expectedFatalMessage string
+	}{
+		"Missing value": {
+			input:                "[Body]\n{\n  \"a\": 1,\n  \"b\":\n}",
+			expectedFatalMessage: "Invalid JSON in [Body] with BodyMerge=json: missing value after object key on line 4",
+		},
+		"Unterminated object": {
+			input:                "[Body]\n{\n  \"a\": 1",
+			expectedFatalMessage: "Invalid JSON in [Body] with BodyMerge=json: unexpected end of JSON input on line 3",
+		},
+		"Trailing data": {
+			input:                "[Body]\n{}\n{}",
+			expectedFatalMessage: "Invalid JSON in [Body] with BodyMerge=json: unexpected data after the JSON value on line 3",
+		},
+	}
+
+	for name, test := range tests {
+		s := newSectionedTemplate(test.input, "")
+		s.setCapturedSections(bodySection)
+		s.getJsonBody()
+
+		if len(s.fatals) != 1 || !strings.HasPrefix(s.fatals[0], test.expectedFatalMessage) {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, s.fatals)
+		}
+	}
+}
+
+func Test_mergeJsonValues(t *testing.T) {
+	base := &jsonObject{keys: []string{"a"}, values: map[string]interface{}{"a": "1"}}
+	override := &jsonObject{keys: []string{"b", "a"}, values: map[string]interface{}{"a": "2", "b": "3"}}
+
+	merged := mergeJsonValues(base, override).(*jsonObject)
+	if !reflect.DeepEqual(merged.keys, []string{"a", "b"}) || merged.values["a"] != "2" || merged.values["b"] != "3" {
+		t.Errorf("Unexpected merge: %+v", merged)
+	}
+}
@@ -10,17 +10,36 @@ type capturedSection struct {
 	heading                string
 	headingSourceLineIndex int
 	sectionLines           *[]sourceMarker
+	replace                bool
 }
 
-func getSectionHeading(templateLineTextTrimmed string) string {
+// Returns the heading and if it has the replace modifier
+func getSectionHeading(templateLineTextTrimmed string) (string, bool) {
 	templateLineTextTrimmedLower := strings.ToLower(templateLineTextTrimmed)
 	for _, knownSectionHeader := range allSectionHeaders {
 		if templateLineTextTrimmedLower == knownSectionHeader {
-			return knownSectionHeader
+			return knownSectionHeader, false
 		}
 	}
 
-	return ""
+	for _, replaceableSectionHeader := range sectionsAllowingReplace {
+		if templateLineTextTrimmedLower == strings.TrimSuffix(replaceableSectionHeader, "]")+replaceSectionModifier+"]" {
+			return replaceableSectionHeader, true
+		}
+	}
+
+	return "", false
+}
+
+func isSectionHeading(templateLineTextTrimmed string) bool {
+	sectionHeading, _ := getSectionHeading(templateLineTextTrimmed)
+	return sectionHeading != ""
+}
+
+// [Request <name>] headings are escaped the same way as section
+// headings, as a file is split on them before the sections are read
+func isEscapableHeading(templateLineTextTrimmed string) bool {
+	return isSectionHeading(templateLineTextTrimmed) || requestHeadingRe.MatchString(templateLineTextTrimmed)
 }
 
 func (s *sectionedTemplate) checkValidHeadings(capturedSections []capturedSection) {
@@ -28,7 +47,8 @@ func (s *sectionedTemplate) checkValidHeadings(capturedSections []capturedSectio
 	headingDefinitionSourceLines := map[string][]int{}
 
 	for _, capturedSection := range capturedSections {
-		if len(*capturedSection.sectionLines) == 0 {
+		// An empty replacing section removes everything inherited
+		if len(*capturedSection.sectionLines) == 0 && !capturedSection.replace {
 			// !! TODO !! Can I use capturedSectionLine or so
 			s.setFatalMessage(fmt.Sprintf("Empty %s section", capturedSection.heading), capturedSection.headingSourceLineIndex)
 		}
@@ -76,31 +96,17 @@ func compactBodySection(currentSectionLines *[]sourceMarker) {
 }
 
 func unescapeSectionHeading(templateLineTextTrimmed, templateLineText string) string {
-	templateLineTextTrimmedLower := strings.ToLower(templateLineTextTrimmed)
-
 	// !! DEPRECATE !! Old way (e g  \[Body])
-	if strings.HasPrefix(templateLineTextTrimmed, `\`) {
-		for _, knownSectionHeader := range allSectionHeaders {
-			if templateLineTextTrimmedLower == `\`+knownSectionHeader {
-				return strings.Replace(templateLineText, `\`, "", 1)
-			}
-		}
+	if strings.HasPrefix(templateLineTextTrimmed, `\`) && isEscapableHeading(strings.TrimPrefix(templateLineTextTrimmed, `\`)) {
+		return strings.Replace(templateLineText, `\`, "", 1)
 	}
 
-	if strings.HasPrefix(templateLineTextTrimmed, "`") {
-		for _, knownSectionHeader := range allSectionHeaders {
-			if templateLineTextTrimmedLower == "`"+knownSectionHeader {
-				return strings.Replace(templateLineText, "`", "", 1)
-			}
-		}
+	if strings.HasPrefix(templateLineTextTrimmed, "`") && isEscapableHeading(strings.TrimPrefix(templateLineTextTrimmed, "`")) {
+		return strings.Replace(templateLineText, "`", "", 1)
 	}
 
-	if strings.HasPrefix(templateLineTextTrimmed, "\\`") {
-		for _, knownSectionHeader := range allSectionHeaders {
-			if templateLineTextTrimmedLower == "\\`"+knownSectionHeader {
-				return strings.Replace(templateLineText, "\\`", "`", 1)
-			}
-		}
+	if strings.HasPrefix(templateLineTextTrimmed, "\\`") && isEscapableHeading(strings.TrimPrefix(templateLineTextTrimmed, "\\`")) {
+		return strings.Replace(templateLineText, "\\`", "`", 1)
 	}
 
 	return templateLineText
@@ -112,24 +118,38 @@ func (s *sectionedTemplate) setCapturedSections(wantedSectionHeadings ...string)
 	var currentSectionHeader string
 	var currentSectionLines *[]sourceMarker
 
+	if s.isBodyFile && containsSectionHeader(bodySection, wantedSectionHeadings) {
+		currentSectionHeader = bodySection
+		currentSectionLines = &[]sourceMarker{}
+
+		capturedSections = append(capturedSections, capturedSection{
+			heading:                bodySection,
+			headingSourceLineIndex: 0,
+			sectionLines:           currentSectionLines,
+		})
+	}
+
 	for expandedSourceIndex, _ := range s.expandedTemplateLines {
 		expandedTemplateLine := &s.expandedTemplateLines[expandedSourceIndex]
 
 		templateLineText := expandedTemplateLine.getTextContent()
+		if s.isBodyFile {
+			templateLineText = expandedTemplateLine.content
+		}
 		templateLineTextTrimmed := strings.TrimSpace(templateLineText)
 
 		if currentSectionHeader != "" {
 			expandedTemplateLine.consumed = true
 		}
 
-		// Discard empty lines, except if it's the [Body] section
-		if currentSectionHeader != bodySection && templateLineTextTrimmed == "" {
+		// Discard empty lines, except in [Body] and the like
+		if !containsSectionHeader(currentSectionHeader, sectionsKeepingWhitespace) && templateLineTextTrimmed == "" {
 			continue
 		}
 
-		if sectionHeading := getSectionHeading(templateLineTextTrimmed); sectionHeading != "" {
-			// Compact [Body] section
-			if currentSectionHeader == bodySection {
+		if sectionHeading, replace := getSectionHeading(templateLineTextTrimmed); sectionHeading != "" && !s.isBodyFile {
+			// Compact [Body] and the like
+			if containsSectionHeader(currentSectionHeader, sectionsKeepingWhitespace) {
 				compactBodySection(currentSectionLines)
 			}
 
@@ -146,6 +166,7 @@ func (s *sectionedTemplate) setCapturedSections(wantedSectionHeadings ...string)
 				heading:                sectionHeading,
 				headingSourceLineIndex: expandedSourceIndex,
 				sectionLines:           currentSectionLines,
+				replace:                replace,
 			})
 
 			expandedTemplateLine.consumed = true
@@ -158,13 +179,15 @@ func (s *sectionedTemplate) setCapturedSections(wantedSectionHeadings ...string)
 			continue
 		}
 
-		templateLineText = unescapeSectionHeading(templateLineTextTrimmed, templateLineText)
+		if !s.isBodyFile {
+			templateLineText = unescapeSectionHeading(templateLineTextTrimmed, templateLineText)
+		}
 
 		sourceMarker := sourceMarker{
 			sourceLineIndex: expandedSourceIndex,
 		}
 
-		if currentSectionHeader == bodySection {
+		if containsSectionHeader(currentSectionHeader, sectionsKeepingWhitespace) {
 			sourceMarker.lineContents = strings.TrimRightFunc(templateLineText, func(r rune) bool { return unicode.IsSpace(r) })
 		} else {
 			sourceMarker.lineContents = strings.TrimSpace(templateLineText)
@@ -173,7 +196,7 @@ func (s *sectionedTemplate) setCapturedSections(wantedSectionHeadings ...string)
 		*currentSectionLines = append(*currentSectionLines, sourceMarker)
 	}
 
-	if currentSectionHeader == bodySection {
+	if containsSectionHeader(currentSectionHeader, sectionsKeepingWhitespace) {
 		compactBodySection(currentSectionLines)
 	}
 
@@ -183,5 +206,6 @@ func (s *sectionedTemplate) setCapturedSections(wantedSectionHeadings ...string)
 
 	for _, capturedSection := range capturedSections {
 		s.sections[capturedSection.heading] = capturedSection.sectionLines
+		s.replacedSections[capturedSection.heading] = capturedSection.replace
 	}
 }
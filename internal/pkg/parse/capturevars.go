@@ -0,0 +1,90 @@
+package parse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/jonaslu/ain/internal/pkg/utils"
+)
+
+var varNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (s *sectionedTemplate) getCaptures() []data.Capture {
+	var captures []data.Capture
+
+	for _, captureSourceMarker := range *s.getNamedSection(captureSection) {
+		sourceLineIndex := captureSourceMarker.sourceLineIndex
+
+		varName, selectorStr, found := strings.Cut(captureSourceMarker.lineContents, "=")
+		if !found {
+			s.setFatalMessage("Missing = in capture, expected VARIABLE = <selector>", sourceLineIndex)
+			continue
+		}
+
+		varName = strings.TrimSpace(varName)
+		if !varNameRe.MatchString(varName) {
+			s.setFatalMessage(fmt.Sprintf("Invalid variable name: %s", varName), sourceLineIndex)
+			continue
+		}
+
+		tokens, err := utils.TokenizeLine(selectorStr)
+		if err != nil {
+			s.setFatalMessage(fmt.Sprintf("Could not parse capture %s", err.Error()), sourceLineIndex)
+			continue
+		}
+
+		if len(tokens) == 0 {
+			s.setFatalMessage("Missing selector in capture", sourceLineIndex)
+			continue
+		}
+
+		selector, rest, err := parseResponseSelector(tokens)
+		if err != nil {
+			s.setFatalMessage(err.Error(), sourceLineIndex)
+			continue
+		}
+
+		if len(rest) > 0 {
+			s.setFatalMessage(fmt.Sprintf("Unexpected %s after selector %s", strings.Join(rest, " "), describeResponseSelector(selector)), sourceLineIndex)
+			continue
+		}
+
+		captures = append(captures, data.Capture{
+			VarName:  varName,
+			Selector: selector,
+			Filename: s.filename,
+			FormatFatal: func(msg string) string {
+				return s.formatFatalMessage(msg, sourceLineIndex)
+			},
+		})
+	}
+
+	return captures
+}
+
+// CaptureValues returns the captured values in the same order as
+// the captures or a report of all captures that could not be made
+func CaptureValues(captures []data.Capture, response *data.Response) ([]string, string) {
+	values := []string{}
+	failures := responseFailures{}
+
+	for _, capture := range captures {
+		value, found, err := selectResponseValue(capture.Selector, response)
+
+		if err != nil {
+			failures.add(capture.Filename, capture.FormatFatal(fmt.Sprintf("Cannot capture %s: %s", capture.VarName, err.Error())))
+			continue
+		}
+
+		if !found {
+			failures.add(capture.Filename, capture.FormatFatal(fmt.Sprintf("Cannot capture %s: %s not found in the response", capture.VarName, describeResponseSelector(capture.Selector))))
+			continue
+		}
+
+		values = append(values, value)
+	}
+
+	return values, failures.getReport("Failed capture")
+}
@@ -0,0 +1,77 @@
+package parse
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_getCapturesFatals(t *testing.T) {
+	tests := map[string]struct {
+		capture       string
+		expectedFatal string
+	}{
+		"Missing equals": {
+			capture:       "TOKEN $.token",
+			expectedFatal: "Missing = in capture, expected VARIABLE = <selector>",
+		},
+		"Invalid variable name": {
+			capture:       "1TOKEN = $.token",
+			expectedFatal: "Invalid variable name: 1TOKEN",
+		},
+		"Missing selector": {
+			capture:       "TOKEN =",
+			expectedFatal: "Missing selector in capture",
+		},
+		"Trailing tokens": {
+			capture:       "TOKEN = $.token == 1",
+			expectedFatal: "Unexpected == 1 after selector body $.token",
+		},
+	}
+
+	for name, test := range tests {
+		s := newSectionedTemplate("[Capture]\n"+test.capture, "")
+		s.setCapturedSections(captureSection)
+		s.getCaptures()
+
+		expectedFatal := s.formatFatalMessage(test.expectedFatal, 1)
+		if len(s.fatals) != 1 || s.fatals[0] != expectedFatal {
+			t.Errorf("Test: %s. Expected fatal %q, got: %v", name, expectedFatal, s.fatals)
+		}
+	}
+}
+
+func Test_CaptureValues(t *testing.T) {
+	response := &data.Response{
+		StatusCode: 200,
+		Headers:    http.Header{"X-Request-Id": []string{"abc"}},
+		Body:       `{"token": "t0k3n", "user": {"id": 7}}`,
+	}
+
+	s := newSectionedTemplate("[Capture]\nTOKEN = $.token\nUSER_ID=body $.user.id\nREQUEST_ID = header x-request-id\nSTATUS = status", "file.ain")
+	s.setCapturedSections(captureSection)
+
+	captures := s.getCaptures()
+	if s.hasFatalMessages() {
+		t.Fatalf("Got unexpected fatals, %s ", s.getFatalMessages())
+	}
+
+	values, report := CaptureValues(captures, response)
+	if report != "" {
+		t.Fatalf("Got unexpected report: %s", report)
+	}
+
+	expectedValues := []string{"t0k3n", "7", "abc", "200"}
+	if !reflect.DeepEqual(values, expectedValues) {
+		t.Errorf("Expected values %v, got: %v", expectedValues, values)
+	}
+
+	_, report = CaptureValues(captures[:1], &data.Response{Body: `{}`})
+
+	expectedReport := "Failed capture in file: file.ain\n" + captures[0].FormatFatal("Cannot capture TOKEN: body $.token not found in the response")
+	if report != expectedReport {
+		t.Errorf("Unexpected report: %s", report)
+	}
+}
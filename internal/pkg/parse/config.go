@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jonaslu/ain/internal/pkg/data"
 	"github.com/pkg/errors"
@@ -11,6 +12,48 @@ import (
 
 var timeoutConfigRe = regexp.MustCompile(`(?i)\s*timeout\s*=\s*(-?\d+)?`)
 var queryDelimRe = regexp.MustCompile(`(?i)\s*querydelim\s*=\s*(.*)`)
+var execCacheRe = regexp.MustCompile(`(?i)\s*execcache\s*=\s*(.*)`)
+var bodyMergeRe = regexp.MustCompile(`(?i)\s*bodymerge\s*=\s*(.*)`)
+
+func parseBodyMergeConfig(configStr string) (bool, string, error) {
+	bodyMergeMatch := bodyMergeRe.FindStringSubmatch(configStr)
+	if len(bodyMergeMatch) != 2 {
+		return false, "", nil
+	}
+
+	bodyMerge := strings.ToLower(strings.TrimSpace(bodyMergeMatch[1]))
+	if bodyMerge != data.BodyMergeJson && bodyMerge != data.BodyMergeNone {
+		return true, "", errors.Errorf("Unknown body merge value: %s, expected %s or %s", bodyMergeMatch[1], data.BodyMergeJson, data.BodyMergeNone)
+	}
+
+	return true, bodyMerge, nil
+}
+
+func parseExecCacheConfig(configStr string) (bool, time.Duration, error) {
+	execCacheMatch := execCacheRe.FindStringSubmatch(configStr)
+	if len(execCacheMatch) != 2 {
+		return false, 0, nil
+	}
+
+	execCacheStr := strings.TrimSpace(execCacheMatch[1])
+
+	// A plain number is seconds, same as the timeout
+	if seconds, err := strconv.ParseInt(execCacheStr, 10, 32); err == nil {
+		execCacheStr = strconv.FormatInt(seconds, 10) + "s"
+	}
+
+	execCache, err := time.ParseDuration(execCacheStr)
+	if err != nil {
+		return true, 0, errors.Errorf("Malformed exec cache value: %s, must be a duration such as 300s or 5m", execCacheMatch[1])
+	}
+
+	// 0 turns off a cache set in an earlier template
+	if execCache < 0 {
+		return true, 0, errors.New("Exec cache duration cannot be negative")
+	}
+
+	return true, execCache, nil
+}
 
 func parseQueryDelim(configStr string) (bool, string, error) {
 	queryDelimMatch := queryDelimRe.FindStringSubmatch(configStr)
@@ -70,6 +113,36 @@ func (s *sectionedTemplate) getConfig() data.Config {
 			continue
 		}
 
+		if isExecCache, execCacheValue, err := parseExecCacheConfig(configLine.lineContents); isExecCache {
+			if config.ExecCache != data.ExecCacheNotSet {
+				s.setFatalMessage("Exec cache config set twice", configLine.sourceLineIndex)
+				return config
+			}
+
+			if err != nil {
+				s.setFatalMessage(err.Error(), configLine.sourceLineIndex)
+				return config
+			}
+
+			config.ExecCache = execCacheValue
+			continue
+		}
+
+		if isBodyMerge, bodyMergeValue, err := parseBodyMergeConfig(configLine.lineContents); isBodyMerge {
+			if config.BodyMerge != data.BodyMergeNotSet {
+				s.setFatalMessage("Body merge config set twice", configLine.sourceLineIndex)
+				return config
+			}
+
+			if err != nil {
+				s.setFatalMessage(err.Error(), configLine.sourceLineIndex)
+				return config
+			}
+
+			config.BodyMerge = bodyMergeValue
+			continue
+		}
+
 		if isQueryDelim, queryDelimValue, err := parseQueryDelim(configLine.lineContents); isQueryDelim {
 			if config.QueryDelim != nil {
 				// !! TODO !! Can have Query delimiter set n times
@@ -11,12 +11,16 @@ import (
 const maximumLevenshteinDistance = 2
 const maximumNumberOfSuggestions = 3
 
-func formatMissingEnvVarErrorMessage(missingEnvVar string) string {
+func formatMissingEnvVarErrorMessage(missingEnvVar string, vars templateVars) string {
 	suggestions := []string{}
 	missingEnvVarLen := len(missingEnvVar)
 
+	keys := vars.keys()
 	for _, envKeyValue := range os.Environ() {
-		key := strings.SplitN(envKeyValue, "=", 2)[0]
+		keys = append(keys, strings.SplitN(envKeyValue, "=", 2)[0])
+	}
+
+	for _, key := range keys {
 		strLength := missingEnvVarLen - len(key)
 		if strLength < 0 {
 			strLength = -strLength
@@ -42,25 +46,67 @@ func formatMissingEnvVarErrorMessage(missingEnvVar string) string {
 	return fmt.Sprintf("Cannot find value for variable %s", missingEnvVar)
 }
 
-func (s *sectionedTemplate) substituteEnvVars() {
-	s.expandTemplateLines(tokenizeEnvVars, func(c token) (string, string) {
-		envVarKey := c.content
-		if envVarKey == "" {
-			return "", "Empty variable"
-		}
+const (
+	defaultValueModifier = ":-"
+	requiredModifier     = ":?"
+)
 
-		// I'll try anything that is not empty, if the user can't set (such as a variable with spaces in bash) it we can't find it anyway.
-		// https://stackoverflow.com/questions/2821043/allowed-characters-in-linux-environment-variable-names
-		value, exists := os.LookupEnv(envVarKey)
+// Splits shell-style modifiers from the variable name:
+// ${VAR:-default} and ${VAR:?message}. The modifier is
+// empty if there is none.
+func splitEnvVarModifier(envVarContent string) (string, string, string) {
+	modifierIdx := strings.Index(envVarContent, ":")
 
-		if !exists {
-			return "", formatMissingEnvVarErrorMessage(envVarKey)
-		}
+	if modifierIdx == -1 {
+		return envVarContent, "", ""
+	}
 
-		if value == "" {
-			return "", fmt.Sprintf("Value for variable %s is empty", envVarKey)
+	for _, modifier := range []string{defaultValueModifier, requiredModifier} {
+		if strings.HasPrefix(envVarContent[modifierIdx:], modifier) {
+			return envVarContent[:modifierIdx], modifier, envVarContent[modifierIdx+len(modifier):]
 		}
+	}
+
+	return envVarContent, "", ""
+}
+
+// Returns the value of the variable or a fatal
+func expandEnvVar(envVarContent string, vars templateVars) (string, string) {
+	envVarKey, modifier, modifierValue := splitEnvVarModifier(envVarContent)
+	if envVarKey == "" {
+		return "", "Empty variable"
+	}
+
+	// I'll try anything that is not empty, if the user can't set (such as a variable with spaces in bash) it we can't find it anyway.
+	// https://stackoverflow.com/questions/2821043/allowed-characters-in-linux-environment-variable-names
+	value, exists := vars.lookup(envVarKey)
 
-		return value, ""
+	if modifier == defaultValueModifier && value == "" {
+		// Like in the shell an empty value also gets the default
+		return modifierValue, ""
+	}
+
+	if modifier == requiredModifier && value == "" && modifierValue != "" {
+		return "", modifierValue
+	}
+
+	if !exists {
+		return "", formatMissingEnvVarErrorMessage(envVarKey, vars)
+	}
+
+	if value == "" {
+		return "", fmt.Sprintf("Value for variable %s is empty", envVarKey)
+	}
+
+	return value, ""
+}
+
+func (s *sectionedTemplate) substituteEnvVars(vars templateVars) {
+	if s.setVars(vars); s.hasFatalMessages() {
+		return
+	}
+
+	s.expandTemplateLines(tokenizeEnvVars, func(c token) (string, string) {
+		return expandEnvVar(c.content, vars)
 	})
 }
@@ -39,12 +39,40 @@ func Test_sectionedTemplate_expandEnvVars_GoodCases(t *testing.T) {
 				expanded:        true,
 			}},
 		},
+		"Default value when missing or empty": {
+			beforeTest: func() {
+				os.Unsetenv("VAR1")
+				os.Setenv("VAR2", "")
+				os.Setenv("VAR3", "value3")
+			},
+			inputTemplate: "${VAR1:-8080} ${VAR2:-} ${VAR3:-default}",
+			expectedResult: []expandedSourceMarker{{
+				content:         "8080  value3",
+				fatalContent:    "8080  value3",
+				comment:         "",
+				sourceLineIndex: 0,
+				expanded:        true,
+			}},
+		},
+		"Required with message when set": {
+			beforeTest: func() {
+				os.Setenv("VAR1", "value1")
+			},
+			inputTemplate: "${VAR1:?log in first}",
+			expectedResult: []expandedSourceMarker{{
+				content:         "value1",
+				fatalContent:    "value1",
+				comment:         "",
+				sourceLineIndex: 0,
+				expanded:        true,
+			}},
+		},
 	}
 	for name, test := range tests {
 		test.beforeTest()
 		s := newSectionedTemplate(test.inputTemplate, "")
 
-		if s.substituteEnvVars(); s.hasFatalMessages() {
+		if s.substituteEnvVars(newTemplateVars()); s.hasFatalMessages() {
 			t.Errorf("Got unexpected fatals, %s ", s.getFatalMessages())
 		} else {
 			if !reflect.DeepEqual(test.expectedResult, s.expandedTemplateLines) {
@@ -79,12 +107,38 @@ func Test_sectionedTemplate_expandEnvVars_BadCases(t *testing.T) {
 			input:                "${VAR}",
 			expectedFatalMessage: "Value for variable VAR is empty",
 		},
+		"Empty variable with default": {
+			beforeTest:           func() {},
+			input:                "${:-default}",
+			expectedFatalMessage: "Empty variable",
+		},
+		"Required variable missing": {
+			beforeTest: func() {
+				os.Unsetenv("VAR")
+			},
+			input:                "${VAR:?log in first with get-token.ain}",
+			expectedFatalMessage: "log in first with get-token.ain on line 1",
+		},
+		"Required variable empty": {
+			beforeTest: func() {
+				os.Setenv("VAR", "")
+			},
+			input:                "${VAR:?log in first with get-token.ain}",
+			expectedFatalMessage: "log in first with get-token.ain on line 1",
+		},
+		"Required variable without message": {
+			beforeTest: func() {
+				os.Setenv("VAR", "")
+			},
+			input:                "${VAR:?}",
+			expectedFatalMessage: "Value for variable VAR is empty",
+		},
 	}
 
 	for name, test := range tests {
 		test.beforeTest()
 		s := newSectionedTemplate(test.input, "")
-		s.substituteEnvVars()
+		s.substituteEnvVars(newTemplateVars())
 
 		if len(s.fatals) != 1 {
 			t.Errorf("Test: %s. Wrong number of fatals", name)
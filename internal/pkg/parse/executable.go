@@ -5,16 +5,47 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"regexp"
 	"strings"
 	"sync"
 
 	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/jonaslu/ain/internal/pkg/disk"
 	"github.com/jonaslu/ain/internal/pkg/utils"
 )
 
 type executableAndArgs struct {
 	executableCmd string
 	args          []string
+
+	// Named executables (e g $(@token ./get-token.sh)) are run
+	// once and the output is inserted at every $(@token)
+	name string
+}
+
+type executableReference struct {
+	sectionedTemplate         *sectionedTemplate
+	expandedTemplateLineIndex int
+}
+
+type namedExecutable struct {
+	definition *executableAndArgs
+	// The definition as written, for comparing redefinitions
+	cmdLine    string
+	references []executableReference
+}
+
+const executableNamePrefix = "@"
+
+var executableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Splits $(@name command args) into the name and the
+// command, the command is empty if it's a reference
+func splitExecutableName(executableAndArgsStr string) (string, string) {
+	nameAndCmdLine := strings.TrimPrefix(executableAndArgsStr, executableNamePrefix)
+
+	name, cmdLine, _ := strings.Cut(nameAndCmdLine, " ")
+	return name, strings.TrimSpace(cmdLine)
 }
 
 type executableOutput struct {
@@ -22,7 +53,7 @@ type executableOutput struct {
 	fatalMessage string
 }
 
-func (s *sectionedTemplate) captureExecutableAndArgs() []executableAndArgs {
+func (s *sectionedTemplate) captureExecutableAndArgs(namedExecutables map[string]*namedExecutable) []executableAndArgs {
 	executables := []executableAndArgs{}
 
 	for expandedTemplateLineIndex, expandedTemplateLine := range s.expandedTemplateLines {
@@ -32,7 +63,7 @@ func (s *sectionedTemplate) captureExecutableAndArgs() []executableAndArgs {
 
 		executableTokens, fatal := tokenizeExecutables(expandedTemplateLine.content)
 		if fatal != "" {
-			s.setFatalMessage(fatal, expandedTemplateLine.sourceLineIndex)
+			s.setFatalMessage(fatal, expandedTemplateLineIndex)
 		}
 
 		if s.hasFatalMessages() {
@@ -53,18 +84,49 @@ func (s *sectionedTemplate) captureExecutableAndArgs() []executableAndArgs {
 				continue
 			}
 
+			name := ""
+			if strings.HasPrefix(executableAndArgsStr, executableNamePrefix) {
+				name, executableAndArgsStr = splitExecutableName(executableAndArgsStr)
+
+				if !executableNameRe.MatchString(name) {
+					s.setFatalMessage(fmt.Sprintf("Invalid executable name: %s%s", executableNamePrefix, name), expandedTemplateLineIndex)
+					continue
+				}
+
+				if _, exists := namedExecutables[name]; !exists {
+					namedExecutables[name] = &namedExecutable{}
+				}
+
+				if executableAndArgsStr == "" {
+					namedExecutables[name].references = append(namedExecutables[name].references, executableReference{s, expandedTemplateLineIndex})
+					executables = append(executables, executableAndArgs{name: name})
+					continue
+				}
+
+				if cmdLine := namedExecutables[name].cmdLine; cmdLine != "" && cmdLine != executableAndArgsStr {
+					s.setFatalMessage(fmt.Sprintf("Executable %s%s already defined as: %s", executableNamePrefix, name, cmdLine), expandedTemplateLineIndex)
+					continue
+				}
+			}
+
 			tokenizedExecutableLine, err := utils.TokenizeLine(executableAndArgsStr)
 			if err != nil {
 				s.setFatalMessage(err.Error(), expandedTemplateLineIndex)
 				continue
 			}
 
-			executable := tokenizedExecutableLine[0]
-
-			executables = append(executables, executableAndArgs{
-				executableCmd: executable,
+			executable := executableAndArgs{
+				executableCmd: tokenizedExecutableLine[0],
 				args:          tokenizedExecutableLine[1:],
-			})
+				name:          name,
+			}
+
+			if name != "" && namedExecutables[name].definition == nil {
+				namedExecutables[name].definition = &executable
+				namedExecutables[name].cmdLine = executableAndArgsStr
+			}
+
+			executables = append(executables, executable)
 		}
 	}
 
@@ -74,11 +136,33 @@ func (s *sectionedTemplate) captureExecutableAndArgs() []executableAndArgs {
 func callExecutables(ctx context.Context, config data.Config, executables []executableAndArgs) []executableOutput {
 	executableResults := make([]executableOutput, len(executables))
 
+	// Index of the first occurrence of each named executable,
+	// that is the only one that's run
+	firstNamedExecutables := map[string]int{}
+
 	wg := sync.WaitGroup{}
 	for i, executable := range executables {
+		if executable.name != "" {
+			if _, exists := firstNamedExecutables[executable.name]; exists {
+				continue
+			}
+
+			firstNamedExecutables[executable.name] = i
+		}
+
 		go func(resultIndex int, executable executableAndArgs) {
 			defer wg.Done()
 
+			cacheKey := ""
+			if config.ExecCache > 0 && ctx.Value(data.NoExecCacheContextValueKey{}) == nil {
+				cacheKey = disk.GetExecCacheKey(executable.executableCmd, executable.args)
+
+				if cachedOutput, found := disk.ReadExecCache(cacheKey, config.ExecCache); found {
+					executableResults[resultIndex].cmdOutput = cachedOutput
+					return
+				}
+			}
+
 			var stdout, stderr bytes.Buffer
 
 			cmd := exec.CommandContext(ctx, executable.executableCmd, executable.args...)
@@ -117,6 +201,12 @@ func callExecutables(ctx context.Context, config data.Config, executables []exec
 				return
 			}
 
+			if cacheKey != "" {
+				// The cache only saves time, failing to
+				// write it is no reason to stop the call
+				_ = disk.WriteExecCache(cacheKey, stdoutStr)
+			}
+
 			executableResults[resultIndex].cmdOutput = stdoutStr
 		}(i, executable)
 
@@ -125,6 +215,12 @@ func callExecutables(ctx context.Context, config data.Config, executables []exec
 
 	wg.Wait()
 
+	for i, executable := range executables {
+		if executable.name != "" {
+			executableResults[i] = executableResults[firstNamedExecutables[executable.name]]
+		}
+	}
+
 	return executableResults
 }
 
@@ -1,9 +1,15 @@
 package parse
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
 )
 
 func Test_sectionedTemplate_insertExecutableOutputGoodCases(t *testing.T) {
@@ -81,3 +87,89 @@ func Test_sectionedTemplate_insertExecutableOutputBadCases(t *testing.T) {
 		}
 	}
 }
+
+func Test_callExecutablesRunsNamedOnce(t *testing.T) {
+	runsFile := filepath.Join(t.TempDir(), "runs")
+	countRuns := executableAndArgs{
+		executableCmd: "sh",
+		args:          []string{"-c", "echo run >> " + runsFile + " && wc -l < " + runsFile},
+		name:          "count",
+	}
+
+	results := callExecutables(context.Background(), data.NewConfig(), []executableAndArgs{countRuns, countRuns})
+
+	for _, result := range results {
+		if result.fatalMessage != "" || strings.TrimSpace(result.cmdOutput) != "1" {
+			t.Errorf("Expected the named executable to run once, got: %v", results)
+		}
+	}
+}
+
+func Test_captureExecutableAndArgsNamedFatals(t *testing.T) {
+	tests := map[string]struct {
+		inputTemplate        string
+		expectedFatalMessage string
+	}{
+		"Invalid name": {
+			inputTemplate:        "$(@1token echo 1)",
+			expectedFatalMessage: "Invalid executable name: @1token",
+		},
+		"Redefined with another command": {
+			inputTemplate:        "$(@token echo 1) $(@token echo 2)",
+			expectedFatalMessage: "Executable @token already defined as: echo 1",
+		},
+	}
+
+	for name, test := range tests {
+		s := newSectionedTemplate(test.inputTemplate, "")
+		s.captureExecutableAndArgs(map[string]*namedExecutable{})
+
+		if len(s.fatals) != 1 {
+			t.Errorf("Test: %s. Wrong number of fatals: %v", name, s.fatals)
+			continue
+		}
+
+		if !strings.Contains(s.fatals[0], test.expectedFatalMessage) {
+			t.Errorf("Test: %s. Unexpected error message: %s", name, s.fatals[0])
+		}
+	}
+}
+
+func Test_callExecutablesExecCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	runsFile := filepath.Join(t.TempDir(), "runs")
+	countRuns := []executableAndArgs{{
+		executableCmd: "sh",
+		args:          []string{"-c", "echo run >> " + runsFile + " && wc -l < " + runsFile},
+	}}
+
+	config := data.NewConfig()
+	config.ExecCache = time.Minute
+
+	getOutput := func(ctx context.Context) string {
+		return strings.TrimSpace(callExecutables(ctx, config, countRuns)[0].cmdOutput)
+	}
+
+	if output := getOutput(context.Background()); output != "1" {
+		t.Fatalf("Expected first run, got: %s", output)
+	}
+
+	if output := getOutput(context.Background()); output != "1" {
+		t.Errorf("Expected cached output, got: %s", output)
+	}
+
+	noExecCacheCtx := context.WithValue(context.Background(), data.NoExecCacheContextValueKey{}, true)
+	if output := getOutput(noExecCacheCtx); output != "2" {
+		t.Errorf("Expected the cache to be bypassed, got: %s", output)
+	}
+
+	cacheFiles, _ := filepath.Glob(filepath.Join(os.Getenv("XDG_CACHE_HOME"), "ain", "*"))
+	if len(cacheFiles) != 1 {
+		t.Fatalf("Expected one cache file, got: %v", cacheFiles)
+	}
+
+	if fileInfo, err := os.Stat(cacheFiles[0]); err != nil || fileInfo.Mode().Perm() != 0600 {
+		t.Errorf("Expected cache file to be readable only by the user, got: %v %v", fileInfo.Mode(), err)
+	}
+}
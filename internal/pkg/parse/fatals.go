@@ -22,6 +22,10 @@ func getLineWithNumberAndContent(lineIndex int, lineContents string, addCaret bo
 }
 
 func (s *sectionedTemplate) setFatalMessage(msg string, expandedSourceLineIndex int) {
+	s.fatals = append(s.fatals, s.formatFatalMessage(msg, expandedSourceLineIndex))
+}
+
+func (s *sectionedTemplate) formatFatalMessage(msg string, expandedSourceLineIndex int) string {
 	var templateContext []string
 
 	expandedTemplateLine := s.expandedTemplateLines[expandedSourceLineIndex]
@@ -59,7 +63,7 @@ func (s *sectionedTemplate) setFatalMessage(msg string, expandedSourceLineIndex
 		message = message + expandedMsg
 	}
 
-	s.fatals = append(s.fatals, message)
+	return message
 }
 
 func (s *sectionedTemplate) getFatalMessages() string {
@@ -0,0 +1,88 @@
+package parse
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+const multipartFilePrefix = "@"
+const multipartAttributeDelim = ";"
+const multipartTypeAttribute = "type"
+
+func (s *sectionedTemplate) getForm() []string {
+	var form []string
+
+	for _, formSourceMarker := range *s.getNamedSection(formSection) {
+		if !strings.Contains(formSourceMarker.lineContents, queryKeyValueDelim) {
+			s.setFatalMessage("Missing = in form field, expected name=value", formSourceMarker.sourceLineIndex)
+			continue
+		}
+
+		form = append(form, formSourceMarker.lineContents)
+	}
+
+	return form
+}
+
+// Parses file.png;type=image/png
+func parseMultipartFile(value string) (string, string, error) {
+	attributes := strings.Split(strings.TrimPrefix(value, multipartFilePrefix), multipartAttributeDelim)
+	filename := strings.TrimSpace(attributes[0])
+	contentType := ""
+
+	if filename == "" {
+		return "", "", fmt.Errorf("Missing file name after %s", multipartFilePrefix)
+	}
+
+	for _, attribute := range attributes[1:] {
+		name, attributeValue, found := strings.Cut(attribute, "=")
+		if !found || strings.TrimSpace(name) != multipartTypeAttribute {
+			return "", "", fmt.Errorf("Unknown multipart file attribute: %s, expected %s=<content-type>", strings.TrimSpace(attribute), multipartTypeAttribute)
+		}
+
+		contentType = strings.TrimSpace(attributeValue)
+	}
+
+	return filename, contentType, nil
+}
+
+func (s *sectionedTemplate) getMultipart() []data.MultipartField {
+	var multipart []data.MultipartField
+
+	for _, multipartSourceMarker := range *s.getNamedSection(multipartSection) {
+		sourceLineIndex := multipartSourceMarker.sourceLineIndex
+
+		nameValue := querySectionKeyValueDelimRegexp.Split(multipartSourceMarker.lineContents, 2)
+		if len(nameValue) != 2 || nameValue[0] == "" {
+			s.setFatalMessage("Missing = in multipart field, expected name=value or name=@file", sourceLineIndex)
+			continue
+		}
+
+		field := data.MultipartField{Name: nameValue[0], Value: nameValue[1]}
+
+		if strings.HasPrefix(field.Value, multipartFilePrefix) {
+			filename, contentType, err := parseMultipartFile(field.Value)
+			if err != nil {
+				s.setFatalMessage(err.Error(), sourceLineIndex)
+				continue
+			}
+
+			filename = s.getTemplateRelativeFilename(filename)
+			if _, err := os.Stat(filename); err != nil {
+				s.setFatalMessage(fmt.Sprintf("Cannot find multipart file %s", filename), sourceLineIndex)
+				continue
+			}
+
+			field.Value = ""
+			field.Filename = filename
+			field.ContentType = contentType
+		}
+
+		multipart = append(multipart, field)
+	}
+
+	return multipart
+}
@@ -0,0 +1,100 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_getMultipart(t *testing.T) {
+	dir := t.TempDir()
+	avatarFilename := filepath.Join(dir, "avatar.png")
+	if err := os.WriteFile(avatarFilename, []byte("png"), 0644); err != nil {
+		t.Fatalf("Could not write test file: %v", err)
+	}
+
+	// A relative file is relative to the template, not where ain is run
+	s := newSectionedTemplate("[Multipart]\nname=ain = ain\nemail=\navatar=@"+avatarFilename+"; type=image/png\nicon=@avatar.png", filepath.Join(dir, "upload.ain"))
+	s.setCapturedSections(multipartSection)
+
+	multipart := s.getMultipart()
+	if s.hasFatalMessages() {
+		t.Fatalf("Unexpected fatals: %s", s.getFatalMessages())
+	}
+
+	expectedMultipart := []data.MultipartField{
+		{Name: "name", Value: "ain = ain"},
+		{Name: "email", Value: ""},
+		{Name: "avatar", Filename: avatarFilename, ContentType: "image/png"},
+		{Name: "icon", Filename: avatarFilename},
+	}
+
+	if !reflect.DeepEqual(multipart, expectedMultipart) {
+		t.Errorf("Unexpected multipart: %+v", multipart)
+	}
+}
+
+func Test_getMultipartBadCases(t *testing.T) {
+	tests := map[string]struct {
+		input                string
+		expectedFatalMessage string
+	}{
+		"Missing equals": {
+			input:                "[Multipart]\nname",
+			expectedFatalMessage: "Missing = in multipart field, expected name=value or name=@file on line 2",
+		},
+		"Missing file name": {
+			input:                "[Multipart]\navatar=@;type=image/png",
+			expectedFatalMessage: "Missing file name after @ on line 2",
+		},
+		"Missing file": {
+			input:                "[Multipart]\navatar=@/does/not/exist.png",
+			expectedFatalMessage: "Cannot find multipart file /does/not/exist.png on line 2",
+		},
+	}
+
+	for name, test := range tests {
+		s := newSectionedTemplate(test.input, "")
+		s.setCapturedSections(multipartSection)
+		s.getMultipart()
+
+		if len(s.fatals) != 1 || !strings.HasPrefix(s.fatals[0], test.expectedFatalMessage) {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, s.fatals)
+		}
+	}
+}
+
+func Test_getBackendInputForm(t *testing.T) {
+	backendInput, fatals := getBackendInput(allSectionRows{
+		host:    "http://localhost",
+		backend: "curl",
+		form:    []string{"user=ain user", "password=p&ss="},
+	}, data.NewConfig())
+
+	if len(fatals) > 0 {
+		t.Fatalf("Unexpected fatals: %v", fatals)
+	}
+
+	if !reflect.DeepEqual(backendInput.Body, []string{"user=ain+user&password=p%26ss%3D"}) {
+		t.Errorf("Unexpected body: %v", backendInput.Body)
+	}
+
+	if !reflect.DeepEqual(backendInput.Headers, []string{"Content-Type: application/x-www-form-urlencoded"}) {
+		t.Errorf("Unexpected headers: %v", backendInput.Headers)
+	}
+
+	backendInput, _ = getBackendInput(allSectionRows{
+		host:    "http://localhost",
+		backend: "curl",
+		headers: []string{"content-type: application/x-www-form-urlencoded; charset=utf-8"},
+		form:    []string{"user=ain"},
+	}, data.NewConfig())
+
+	if len(backendInput.Headers) != 1 {
+		t.Errorf("Expected the template Content-Type to be kept, got: %v", backendInput.Headers)
+	}
+}
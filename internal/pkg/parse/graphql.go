@@ -0,0 +1,202 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	graphQLCommentPrefix     = "#"
+	graphQLStringDelim       = `"`
+	graphQLBlockStringDelim  = `"""`
+	graphQLEscapedBlockDelim = `\"""`
+)
+
+var graphQLOperationTypes = []string{"query", "mutation", "subscription"}
+
+var graphQLNameRegexp = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*`)
+
+// Calls visit with the index of every character outside of strings
+// until it returns false. A block string can span several lines
+// so if one is open at the end is returned and passed in again.
+func scanGraphQL(text string, inBlockString bool, visit func(idx int) bool) bool {
+	inString := false
+
+	for idx := 0; idx < len(text); {
+		rest := text[idx:]
+
+		switch {
+		case inBlockString && strings.HasPrefix(rest, graphQLEscapedBlockDelim):
+			idx += len(graphQLEscapedBlockDelim)
+
+		case inBlockString && strings.HasPrefix(rest, graphQLBlockStringDelim):
+			inBlockString = false
+			idx += len(graphQLBlockStringDelim)
+
+		case inBlockString:
+			idx++
+
+		case inString && strings.HasPrefix(rest, `\`):
+			idx += 2
+
+		case inString && strings.HasPrefix(rest, graphQLStringDelim):
+			inString = false
+			idx++
+
+		case inString:
+			idx++
+
+		case strings.HasPrefix(rest, graphQLBlockStringDelim):
+			inBlockString = true
+			idx += len(graphQLBlockStringDelim)
+
+		case strings.HasPrefix(rest, graphQLStringDelim):
+			inString = true
+			idx++
+
+		default:
+			if !visit(idx) {
+				return inBlockString
+			}
+
+			idx++
+		}
+	}
+
+	return inBlockString
+}
+
+// The comment is cut off when the template is read. A # inside
+// a GraphQL string is not a comment though so it's put back.
+func (s *sectionedTemplate) getGraphQLQuery() string {
+	queryLines := []string{}
+	inBlockString := false
+
+	for _, graphQLSourceMarker := range *s.getNamedSection(graphQLSection) {
+		line := graphQLSourceMarker.lineContents
+
+		expandedTemplateLine := s.expandedTemplateLines[graphQLSourceMarker.sourceLineIndex]
+		if expandedTemplateLine.comment != "" {
+			line = expandedTemplateLine.getTextContent() + expandedTemplateLine.comment
+		}
+
+		commentIdx := -1
+		inBlockString = scanGraphQL(line, inBlockString, func(idx int) bool {
+			if strings.HasPrefix(line[idx:], graphQLCommentPrefix) {
+				commentIdx = idx
+				return false
+			}
+
+			return true
+		})
+
+		if commentIdx > -1 {
+			line = line[:commentIdx]
+
+			// Leave out lines with only a comment
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+		}
+
+		queryLines = append(queryLines, strings.TrimRightFunc(line, unicode.IsSpace))
+	}
+
+	return strings.Join(queryLines, "\n")
+}
+
+func (s *sectionedTemplate) getGraphQLVariables() interface{} {
+	graphQLVariablesSourceMarkers := *s.getNamedSection(graphQLVariablesSection)
+	if len(graphQLVariablesSourceMarkers) == 0 {
+		return nil
+	}
+
+	variables, ok := s.decodeJsonLines(graphQLVariablesSourceMarkers, "Invalid JSON in [GraphQLVariables]")
+	if !ok {
+		return nil
+	}
+
+	if _, isObject := variables.(*jsonObject); !isObject {
+		s.setFatalMessage("[GraphQLVariables] must be a JSON object", graphQLVariablesSourceMarkers[0].sourceLineIndex)
+		return nil
+	}
+
+	return variables
+}
+
+// The name is only needed to pick one of several operations, but
+// servers accept it for a single one. Returns the name if the query
+// has exactly one operation and that one is named.
+func getGraphQLOperationName(query string) string {
+	operationHeadings := []string{}
+	braceDepth, parenDepth := 0, 0
+	definitionStart := 0
+
+	scanGraphQL(query, false, func(idx int) bool {
+		// Braces in arguments are object values, e g ($filter: Filter = { active: true })
+		switch {
+		case query[idx] == '(':
+			parenDepth++
+		case query[idx] == ')':
+			parenDepth--
+		case parenDepth > 0:
+		case query[idx] == '{':
+			if braceDepth == 0 {
+				operationHeadings = append(operationHeadings, strings.TrimSpace(query[definitionStart:idx]))
+			}
+
+			braceDepth++
+		case query[idx] == '}':
+			if braceDepth--; braceDepth == 0 {
+				definitionStart = idx + 1
+			}
+		}
+
+		return true
+	})
+
+	operationName := ""
+	operations := 0
+
+	for _, operationHeading := range operationHeadings {
+		operationType := graphQLNameRegexp.FindString(operationHeading)
+		rest := strings.TrimSpace(strings.TrimPrefix(operationHeading, operationType))
+		if operationType == "fragment" {
+			continue
+		}
+
+		operations++
+		for _, graphQLOperationType := range graphQLOperationTypes {
+			if operationType == graphQLOperationType {
+				operationName = graphQLNameRegexp.FindString(rest)
+			}
+		}
+	}
+
+	if operations != 1 {
+		return ""
+	}
+
+	return operationName
+}
+
+// Wraps the query in the JSON body a GraphQL server expects
+func getGraphQLBody(query string, variables interface{}) []string {
+	graphQLBody := &jsonObject{
+		keys:   []string{"query"},
+		values: map[string]interface{}{"query": query},
+	}
+
+	if variables != nil {
+		graphQLBody.keys = append(graphQLBody.keys, "variables")
+		graphQLBody.values["variables"] = variables
+	}
+
+	if operationName := getGraphQLOperationName(query); operationName != "" {
+		graphQLBody.keys = append(graphQLBody.keys, "operationName")
+		graphQLBody.values["operationName"] = operationName
+	}
+
+	return strings.Split(formatJsonValue(graphQLBody), "\n")
+}
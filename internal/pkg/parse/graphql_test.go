@@ -0,0 +1,91 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_getGraphQLQuery(t *testing.T) {
+	template := "[GraphQL]\n# Fetches a user\nquery GetUser($color: String = \"#fff\") {\n  user { # the user\n    bio(format: \"\"\"\n      # kept\n    \"\"\")\n\n    name `# escaped\n  }\n}"
+
+	s := newSectionedTemplate(template, "")
+	s.setCapturedSections(graphQLSection)
+
+	expectedQuery := "query GetUser($color: String = \"#fff\") {\n  user {\n    bio(format: \"\"\"\n      # kept\n    \"\"\")\n\n    name\n  }\n}"
+	if query := s.getGraphQLQuery(); query != expectedQuery {
+		t.Errorf("Unexpected query: %s", query)
+	}
+}
+
+func Test_getGraphQLOperationName(t *testing.T) {
+	tests := map[string]struct {
+		query                 string
+		expectedOperationName string
+	}{
+		"Named query": {
+			query:                 "query GetUser($id: ID!, $filter: Filter = { active: true }) { user(id: $id) { name } }",
+			expectedOperationName: "GetUser",
+		},
+		"Named mutation with fragment": {
+			query:                 "fragment F on User { name }\nmutation AddUser{ addUser { ...F } }",
+			expectedOperationName: "AddUser",
+		},
+		"Anonymous": {
+			query: "{ user { name } }",
+		},
+		"Unnamed query": {
+			query: "query($id: ID) { user(id: $id) { name } }",
+		},
+		"Several operations": {
+			query: "query A { a }\nquery B { b }",
+		},
+		"Braces in strings": {
+			query:                 "query A { a(text: \"{ }\") }",
+			expectedOperationName: "A",
+		},
+	}
+
+	for name, test := range tests {
+		if operationName := getGraphQLOperationName(test.query); operationName != test.expectedOperationName {
+			t.Errorf("Test: %s. Unexpected operation name: %s", name, operationName)
+		}
+	}
+}
+
+func Test_getGraphQLVariablesBadCases(t *testing.T) {
+	tests := map[string]struct {
+		template      string
+		expectedFatal string
+	}{
+		"Invalid JSON": {
+			template:      "[GraphQLVariables]\n{\n  \"id\": ,\n}",
+			expectedFatal: "Invalid JSON in [GraphQLVariables]: invalid character ',' looking for beginning of value on line 3",
+		},
+		"Not an object": {
+			template:      "[GraphQLVariables]\n[1, 2]",
+			expectedFatal: "[GraphQLVariables] must be a JSON object on line 2",
+		},
+	}
+
+	for name, test := range tests {
+		s := newSectionedTemplate(test.template, "")
+		s.setCapturedSections(graphQLVariablesSection)
+
+		if variables := s.getGraphQLVariables(); variables != nil || !strings.Contains(s.getFatalMessages(), test.expectedFatal) {
+			t.Errorf("Test: %s. Unexpected fatal: %s", name, s.getFatalMessages())
+		}
+	}
+}
+
+func Test_getGraphQLBody(t *testing.T) {
+	variables, _ := newSectionedTemplate("", "").decodeJsonLines([]sourceMarker{{lineContents: "{ \"id\": \"1\" }"}}, "")
+
+	expectedBody := "{\n  \"query\": \"query GetUser($id: ID!) {\\n  user(id: $id) { name }\\n}\",\n  \"variables\": {\n    \"id\": \"1\"\n  },\n  \"operationName\": \"GetUser\"\n}"
+	if body := getGraphQLBody("query GetUser($id: ID!) {\n  user(id: $id) { name }\n}", variables); strings.Join(body, "\n") != expectedBody {
+		t.Errorf("Unexpected body: %s", strings.Join(body, "\n"))
+	}
+
+	if body := getGraphQLBody("{ a }", nil); strings.Join(body, "\n") != "{\n  \"query\": \"{ a }\"\n}" {
+		t.Errorf("Unexpected body without variables: %s", strings.Join(body, "\n"))
+	}
+}
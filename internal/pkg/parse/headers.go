@@ -1,11 +1,118 @@
 package parse
 
-func (s *sectionedTemplate) getHeaders() []string {
+import (
+	"fmt"
+	"strings"
+)
+
+// Removes an inherited header or query parameter, e g -Authorization
+const removeLinePrefix = "-"
+
+// Keeps earlier headers with the same name, e g +Accept: text/html
+const repeatableHeaderPrefix = "+"
+
+func getHeaderName(header string) string {
+	headerName, _, _ := strings.Cut(strings.TrimPrefix(header, repeatableHeaderPrefix), ":")
+	return strings.TrimSpace(headerName)
+}
+
+// Returns the headers and the names of inherited headers to remove
+func (s *sectionedTemplate) getHeaders() ([]string, []string) {
 	var headers []string
+	var removedHeaderNames []string
+
+	for _, headerSourceMarker := range *s.getNamedSection(headersSection) {
+		if !strings.HasPrefix(headerSourceMarker.lineContents, removeLinePrefix) {
+			headers = append(headers, headerSourceMarker.lineContents)
+			continue
+		}
+
+		removedHeaderName := strings.TrimSpace(strings.TrimPrefix(headerSourceMarker.lineContents, removeLinePrefix))
+		if removedHeaderName == "" || strings.Contains(removedHeaderName, ":") {
+			s.setFatalMessage("Remove a header by name only, e g -Authorization", headerSourceMarker.sourceLineIndex)
+			continue
+		}
+
+		removedHeaderNames = append(removedHeaderNames, removedHeaderName)
+	}
+
+	return headers, removedHeaderNames
+}
+
+// Same header name twice in a template is likely a mistake as only the last is sent
+func (s *sectionedTemplate) getHeaderWarnings() []string {
+	warnings := []string{}
+	headerNameSourceLineIndexes := map[string]int{}
 
 	for _, headerSourceMarker := range *s.getNamedSection(headersSection) {
-		headers = append(headers, headerSourceMarker.lineContents)
+		header := headerSourceMarker.lineContents
+		if strings.HasPrefix(header, removeLinePrefix) || strings.HasPrefix(header, repeatableHeaderPrefix) || !strings.Contains(header, ":") {
+			continue
+		}
+
+		headerName := strings.ToLower(getHeaderName(header))
+		if previousSourceLineIndex, exists := headerNameSourceLineIndexes[headerName]; exists {
+			msg := fmt.Sprintf("Header %s on line %d is overridden by the same header", getHeaderName(header), s.expandedTemplateLines[previousSourceLineIndex].sourceLineIndex+1)
+			warnings = append(warnings, s.formatFatalMessage(msg, headerSourceMarker.sourceLineIndex))
+		}
+
+		headerNameSourceLineIndexes[headerName] = headerSourceMarker.sourceLineIndex
+	}
+
+	return warnings
+}
+
+// Later headers replace earlier ones with the same name, case-insensitive,
+// unless marked as repeatable. The replacing header goes last. Lines
+// without a colon have no name and are passed on as is.
+func dedupeHeaders(headers []string) []string {
+	dedupedHeaders := []string{}
+
+	for _, header := range headers {
+		if strings.HasPrefix(header, repeatableHeaderPrefix) {
+			dedupedHeaders = append(dedupedHeaders, strings.TrimSpace(strings.TrimPrefix(header, repeatableHeaderPrefix)))
+			continue
+		}
+
+		if !strings.Contains(header, ":") {
+			dedupedHeaders = append(dedupedHeaders, header)
+			continue
+		}
+
+		dedupedHeaders = append(removeHeaders(dedupedHeaders, []string{getHeaderName(header)}), header)
+	}
+
+	return dedupedHeaders
+}
+
+func removeHeaders(headers, removedHeaderNames []string) []string {
+	keptHeaders := []string{}
+
+	for _, header := range headers {
+		if !containsFold(removedHeaderNames, getHeaderName(header)) {
+			keptHeaders = append(keptHeaders, header)
+		}
+	}
+
+	return keptHeaders
+}
+
+func containsFold(values []string, wantedValue string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, wantedValue) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasHeader(headers []string, wantedHeaderName string) bool {
+	for _, header := range headers {
+		if strings.EqualFold(getHeaderName(header), wantedHeaderName) {
+			return true
+		}
 	}
 
-	return headers
+	return false
 }
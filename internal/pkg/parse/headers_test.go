@@ -0,0 +1,47 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_dedupeHeaders(t *testing.T) {
+	tests := map[string]struct {
+		headers         []string
+		expectedHeaders []string
+	}{
+		"Last wins": {
+			headers:         []string{"Accept: */*", "X-Id: 1", "accept: application/json"},
+			expectedHeaders: []string{"X-Id: 1", "accept: application/json"},
+		},
+		"Repeatable is kept": {
+			headers:         []string{"Cookie: a=1", "+Cookie: b=2", "+ cookie: c=3"},
+			expectedHeaders: []string{"Cookie: a=1", "Cookie: b=2", "cookie: c=3"},
+		},
+		"Later header replaces repeatable": {
+			headers:         []string{"+Cookie: a=1", "+Cookie: b=2", "Cookie: c=3"},
+			expectedHeaders: []string{"Cookie: c=3"},
+		},
+		"No colon is passed on": {
+			headers:         []string{"[Headers]", "[Headers]"},
+			expectedHeaders: []string{"[Headers]", "[Headers]"},
+		},
+	}
+
+	for name, test := range tests {
+		if headers := dedupeHeaders(test.headers); !reflect.DeepEqual(headers, test.expectedHeaders) {
+			t.Errorf("Test: %s. Unexpected headers: %v", name, headers)
+		}
+	}
+}
+
+func Test_getHeaderWarnings(t *testing.T) {
+	template := newSectionedTemplate("[Headers]\nAccept: */*\n+Cookie: a=1\n+Cookie: b=2\n-X-Id\naccept: application/json", "")
+	template.setCapturedSections(headersSection)
+
+	warnings := template.getHeaderWarnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Header accept on line 2 is overridden by the same header") {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+}
@@ -0,0 +1,165 @@
+package parse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/disk"
+)
+
+// Builds the chain of templates where any [Include]:d files
+// come before the template including them
+type templateChain struct {
+	sectionedTemplates []*sectionedTemplate
+	fatals             []string
+
+	// Keys of the files already in the chain, so a file
+	// included by several templates is only added once
+	added map[string]bool
+
+	includedBy map[*sectionedTemplate]string
+}
+
+func newTemplateChain() templateChain {
+	return templateChain{
+		added:      map[string]bool{},
+		includedBy: map[*sectionedTemplate]string{},
+	}
+}
+
+// Relative filenames in a template are relative to
+// the folder of the template, not where ain is run
+func (s *sectionedTemplate) getTemplateRelativeFilename(filename string) string {
+	if filepath.IsAbs(filename) {
+		return filename
+	}
+
+	return filepath.Join(filepath.Dir(s.filename), filename)
+}
+
+func getIncludeKey(filename, requestName string) string {
+	absFilename, err := filepath.Abs(filename)
+	if err != nil {
+		absFilename = filepath.Clean(filename)
+	}
+
+	return absFilename + requestNameSeparator + requestName
+}
+
+// includeStack holds the files including this one, for finding cycles
+func (c *templateChain) addTemplateFile(filename, requestName string, editFile bool, includeStack []string) error {
+	rawTemplateString, err := disk.ReadRawTemplateString(filename, editFile)
+	if err != nil {
+		return err
+	}
+
+	requestSectionedTemplates, fatal, err := getRequestSectionedTemplates(rawTemplateString, filename, requestName)
+	if err != nil {
+		return err
+	}
+
+	if fatal != "" {
+		c.fatals = append(c.fatals, fatal)
+		return nil
+	}
+
+	for _, sectionedTemplate := range requestSectionedTemplates {
+		// The shared part of a file with several requests
+		// is only added once, whichever requests are included
+		addedKey := getIncludeKey(splitRequestName(sectionedTemplate.filename))
+		if c.added[addedKey] {
+			continue
+		}
+
+		c.added[addedKey] = true
+
+		templateIncludeStack := append(append([]string{}, includeStack...), sectionedTemplate.filename)
+		c.addIncludes(sectionedTemplate, filename, templateIncludeStack)
+
+		c.sectionedTemplates = append(c.sectionedTemplates, sectionedTemplate)
+		if len(includeStack) > 0 {
+			c.includedBy[sectionedTemplate] = includeStack[len(includeStack)-1]
+		}
+
+		c.addExpandedBodyFile(sectionedTemplate)
+	}
+
+	return nil
+}
+
+func (c *templateChain) addIncludes(s *sectionedTemplate, filename string, includeStack []string) {
+	if s.setCapturedSections(includeSection); s.hasFatalMessages() {
+		c.fatals = append(c.fatals, s.getFatalMessages())
+		return
+	}
+
+	for _, includeSourceMarker := range *s.getNamedSection(includeSection) {
+		includeFilename, includeRequestName := splitRequestName(s.getTemplateRelativeFilename(includeSourceMarker.lineContents))
+		includeDisplayName := includeFilename
+		if includeRequestName != "" {
+			includeDisplayName = includeFilename + requestNameSeparator + includeRequestName
+		}
+
+		includeKey := getIncludeKey(includeFilename, includeRequestName)
+
+		inIncludeStack := false
+		for _, stackFilename := range includeStack {
+			stackFilename, stackRequestName := splitRequestName(stackFilename)
+			if getIncludeKey(stackFilename, stackRequestName) == includeKey {
+				inIncludeStack = true
+			}
+		}
+
+		if inIncludeStack {
+			s.setFatalMessage(fmt.Sprintf("Include cycle: %s -> %s", strings.Join(includeStack, " -> "), includeDisplayName), includeSourceMarker.sourceLineIndex)
+			continue
+		}
+
+		if c.added[includeKey] {
+			continue
+		}
+
+		if _, err := os.Stat(includeFilename); err != nil {
+			s.setFatalMessage(fmt.Sprintf("Cannot find included file %s", includeFilename), includeSourceMarker.sourceLineIndex)
+			continue
+		}
+
+		if err := c.addTemplateFile(includeFilename, includeRequestName, false, includeStack); err != nil {
+			s.setFatalMessage(fmt.Sprintf("Cannot include %s: %s", includeDisplayName, err.Error()), includeSourceMarker.sourceLineIndex)
+		}
+	}
+
+	if s.hasFatalMessages() {
+		c.fatals = append(c.fatals, s.getFatalMessages())
+	}
+}
+
+type ExplainedTemplate struct {
+	Filename   string
+	IncludedBy string
+}
+
+// ExplainTemplates returns the templates in the order they are
+// assembled and the template including them, if any
+func ExplainTemplates(filenames []string) ([]ExplainedTemplate, string, error) {
+	chain, err := getTemplateChain(filenames)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(chain.fatals) > 0 {
+		return nil, strings.Join(chain.fatals, "\n\n"), nil
+	}
+
+	explainedTemplates := []ExplainedTemplate{}
+	for _, sectionedTemplate := range chain.sectionedTemplates {
+		explainedTemplates = append(explainedTemplates, ExplainedTemplate{
+			Filename:   sectionedTemplate.filename,
+			IncludedBy: chain.includedBy[sectionedTemplate],
+		})
+	}
+
+	return explainedTemplates, "", nil
+}
@@ -0,0 +1,105 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeIncludeTestFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for filename, contents := range files {
+		filePath := filepath.Join(dir, filename)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func Test_getAllSectionedTemplatesIncludes(t *testing.T) {
+	dir := writeIncludeTestFiles(t, map[string]string{
+		"base.template":       "[Backend]\ncurl",
+		"auth/token.template": "[Include]\n../base.template\n[Headers]\nAuthorization: Bearer 1",
+		"users/get.ain":       "[Include]\n../base.template\n../auth/token.template\n[Host]\nlocalhost",
+		"users/requests.ain":  "[Include]\n../base.template\n\n[Request list]\n[Include]\n../auth/token.template\n[Host]\nlocalhost/users\n[Request get]\n[Host]\nlocalhost/users/1",
+		"users/both.ain":      "[Include]\nrequests.ain`#list\nrequests.ain`#get #not-a-request\n[Method]\nPOST",
+	})
+
+	tests := map[string]struct {
+		filename          string
+		expectedFilenames []string
+	}{
+		"Includes come first and are only added once": {
+			filename:          "users/get.ain",
+			expectedFilenames: []string{"base.template", "auth/token.template", "users/get.ain"},
+		},
+		"Includes in a request": {
+			filename:          "users/requests.ain#list",
+			expectedFilenames: []string{"base.template", "users/requests.ain", "auth/token.template", "users/requests.ain#list"},
+		},
+		"Shared part of several included requests is only added once": {
+			filename:          "users/both.ain",
+			expectedFilenames: []string{"base.template", "users/requests.ain", "auth/token.template", "users/requests.ain#list", "users/requests.ain#get", "users/both.ain"},
+		},
+	}
+
+	for name, test := range tests {
+		sectionedTemplates, fatals, err := getAllSectionedTemplates([]string{filepath.Join(dir, test.filename)})
+		if err != nil || len(fatals) > 0 {
+			t.Errorf("Test: %s. Unexpected error: %v %v", name, err, fatals)
+			continue
+		}
+
+		filenames := []string{}
+		for _, sectionedTemplate := range sectionedTemplates {
+			relFilename, _ := filepath.Rel(dir, sectionedTemplate.filename)
+			filenames = append(filenames, filepath.ToSlash(relFilename))
+		}
+
+		if strings.Join(filenames, ",") != strings.Join(test.expectedFilenames, ",") {
+			t.Errorf("Test: %s. Unexpected template order: %v", name, filenames)
+		}
+	}
+}
+
+func Test_getAllSectionedTemplatesIncludesBadCases(t *testing.T) {
+	dir := writeIncludeTestFiles(t, map[string]string{
+		"a.ain":       "[Include]\nb.template",
+		"b.template":  "[Include]\na.ain",
+		"missing.ain": "[Include]\nnot-here.template",
+	})
+
+	tests := map[string]struct {
+		filename             string
+		expectedFatalMessage string
+	}{
+		"Include cycle": {
+			filename:             "a.ain",
+			expectedFatalMessage: "Include cycle: " + filepath.Join(dir, "a.ain") + " -> " + filepath.Join(dir, "b.template") + " -> " + filepath.Join(dir, "a.ain") + " on line 2",
+		},
+		"Missing include": {
+			filename:             "missing.ain",
+			expectedFatalMessage: "Cannot find included file " + filepath.Join(dir, "not-here.template") + " on line 2",
+		},
+	}
+
+	for name, test := range tests {
+		_, fatals, err := getAllSectionedTemplates([]string{filepath.Join(dir, test.filename)})
+		if err != nil {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+			continue
+		}
+
+		if len(fatals) != 1 || !strings.Contains(fatals[0], test.expectedFatalMessage) {
+			t.Errorf("Test: %s. Unexpected fatals: %v", name, fatals)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package parse
+
+import (
+	"context"
+	"time"
+
+	"github.com/jonaslu/ain/internal/pkg/call"
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/jonaslu/ain/internal/pkg/disk"
+)
+
+// A token about to expire is fetched again so
+// it does not expire while the call is made
+const tokenExpirySkew = 30 * time.Second
+
+// Printed in place of the token when no call is made, so
+// printing needs neither network access nor credentials
+const printedOAuth2Token = "<oauth2-token>"
+
+// Returns the access token from the cache if
+// it's still valid, otherwise it's fetched and cached
+func getOAuth2Token(ctx context.Context, oauth2 *data.OAuth2) (string, error) {
+	cacheKey := disk.GetTokenCacheKey(oauth2.GrantType, oauth2.TokenUrl, oauth2.ClientId, oauth2.ClientSecret, oauth2.Scope, oauth2.RefreshToken)
+	refreshToken := oauth2.RefreshToken
+
+	if cachedToken, found := disk.ReadTokenCache(cacheKey); found {
+		if ctx.Value(data.NoExecCacheContextValueKey{}) == nil && time.Now().Add(tokenExpirySkew).Before(cachedToken.ExpiresAt) {
+			return cachedToken.AccessToken, nil
+		}
+
+		// The one in the template may have been used up
+		if cachedToken.RefreshToken != "" {
+			refreshToken = cachedToken.RefreshToken
+		}
+	}
+
+	token, err := call.FetchOAuth2Token(ctx, oauth2, refreshToken)
+	if err != nil {
+		return "", err
+	}
+
+	latestRefreshToken := refreshToken
+	if token.RefreshToken != "" {
+		latestRefreshToken = token.RefreshToken
+	}
+
+	rotatedRefreshToken := oauth2.GrantType == data.RefreshTokenGrant && latestRefreshToken != oauth2.RefreshToken
+
+	// Without an expiry there's no telling how long the access
+	// token is valid, but a rotated refresh token is kept
+	if token.ExpiresIn > 0 || rotatedRefreshToken {
+		cachedToken := disk.CachedToken{
+			AccessToken: token.AccessToken,
+			ExpiresAt:   time.Now().Add(token.ExpiresIn),
+		}
+
+		if rotatedRefreshToken {
+			cachedToken.RefreshToken = latestRefreshToken
+		}
+
+		// The cache only saves time, failing to
+		// write it is no reason to stop the call
+		_ = disk.WriteTokenCache(cacheKey, cachedToken)
+	}
+
+	return token.AccessToken, nil
+}
@@ -0,0 +1,61 @@
+package parse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_getOAuth2TokenCaches(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	fetched := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		fetched++
+
+		// Rotates the refresh token on every call
+		if r.PostForm.Get("refresh_token") != fmt.Sprintf("refresh-%d", fetched-1) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":%d,"refresh_token":"refresh-%d"}`, fetched, 3600*(fetched%2), fetched)
+	}))
+	defer server.Close()
+
+	oauth2 := &data.OAuth2{
+		GrantType:    data.RefreshTokenGrant,
+		TokenUrl:     server.URL,
+		ClientId:     "id",
+		RefreshToken: "refresh-0",
+	}
+
+	expectedTokens := []struct {
+		ctx           context.Context
+		expectedToken string
+	}{
+		{context.Background(), "token-1"},
+		// Still valid
+		{context.Background(), "token-1"},
+		// Cache skipped, uses the rotated refresh token
+		{context.WithValue(context.Background(), data.NoExecCacheContextValueKey{}, true), "token-2"},
+		// token-2 had no expiry so it's fetched again
+		{context.Background(), "token-3"},
+	}
+
+	for i, expected := range expectedTokens {
+		token, err := getOAuth2Token(expected.ctx, oauth2)
+		if err != nil {
+			t.Fatalf("Call %d. Unexpected error: %v", i, err)
+		}
+
+		if token != expected.expectedToken {
+			t.Errorf("Call %d. Expected %s, got: %s", i, expected.expectedToken, token)
+		}
+	}
+}
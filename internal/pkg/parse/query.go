@@ -1,11 +1,49 @@
 package parse
 
-func (s *sectionedTemplate) getQuery() []string {
+import "strings"
+
+func getQueryKey(queryLine string) string {
+	return querySectionKeyValueDelimRegexp.Split(queryLine, 2)[0]
+}
+
+// Returns the query and the keys of inherited query parameters to remove
+func (s *sectionedTemplate) getQuery() ([]string, []string) {
 	var query []string
+	var removedQueryKeys []string
 
 	for _, querySourceMarker := range *s.getNamedSection(querySection) {
-		query = append(query, querySourceMarker.lineContents)
+		if !strings.HasPrefix(querySourceMarker.lineContents, removeLinePrefix) {
+			query = append(query, querySourceMarker.lineContents)
+			continue
+		}
+
+		removedQueryKey := strings.TrimSpace(strings.TrimPrefix(querySourceMarker.lineContents, removeLinePrefix))
+		if removedQueryKey == "" || strings.Contains(removedQueryKey, queryKeyValueDelim) {
+			s.setFatalMessage("Remove a query parameter by key only, e g -page", querySourceMarker.sourceLineIndex)
+			continue
+		}
+
+		removedQueryKeys = append(removedQueryKeys, removedQueryKey)
+	}
+
+	return query, removedQueryKeys
+}
+
+func removeQuery(query, removedQueryKeys []string) []string {
+	keptQuery := []string{}
+
+	for _, queryLine := range query {
+		removed := false
+		for _, removedQueryKey := range removedQueryKeys {
+			if getQueryKey(queryLine) == removedQueryKey {
+				removed = true
+			}
+		}
+
+		if !removed {
+			keptQuery = append(keptQuery, queryLine)
+		}
 	}
 
-	return query
+	return keptQuery
 }
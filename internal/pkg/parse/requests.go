@@ -0,0 +1,167 @@
+package parse
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/disk"
+	"github.com/pkg/errors"
+)
+
+// Selects a named request in a file, e g api.ain#get-user
+const requestNameSeparator = "#"
+
+var requestHeadingRe = regexp.MustCompile(`(?i)^\[request(\s+.*)?\]$`)
+var requestNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+type requestBlock struct {
+	name                   string
+	headingSourceLineIndex int
+	sourceLineIndexes      []int
+}
+
+// A file with [Request name] headings holds several requests.
+// Lines before the first heading are shared by all of them.
+type requestBlocks struct {
+	sharedSourceLineIndexes []int
+	requests                []requestBlock
+}
+
+func (r requestBlocks) getNames() []string {
+	names := []string{}
+	for _, request := range r.requests {
+		names = append(names, request.name)
+	}
+
+	return names
+}
+
+func (r requestBlocks) getRequest(name string) (requestBlock, bool) {
+	for _, request := range r.requests {
+		if request.name == name {
+			return request, true
+		}
+	}
+
+	return requestBlock{}, false
+}
+
+// Splits api.ain#get-user into the filename and the request name,
+// unless the whole thing is the name of an existing file
+func splitRequestName(filename string) (string, string) {
+	idx := strings.LastIndex(filename, requestNameSeparator)
+	if idx == -1 {
+		return filename, ""
+	}
+
+	if _, err := os.Stat(filename); err == nil {
+		return filename, ""
+	}
+
+	return filename[:idx], filename[idx+1:]
+}
+
+func (s *sectionedTemplate) getRequestBlocks() requestBlocks {
+	blocks := requestBlocks{}
+	var currentRequest *requestBlock
+
+	for sourceLineIndex, rawTemplateLine := range s.rawTemplateLines {
+		content, _ := splitTextOnComment(rawTemplateLine)
+		contentTrimmed := strings.TrimSpace(content)
+
+		if requestHeadingMatch := requestHeadingRe.FindStringSubmatch(contentTrimmed); requestHeadingMatch != nil {
+			name := strings.TrimSpace(requestHeadingMatch[1])
+
+			if name == "" {
+				s.setFatalMessage("Missing request name, expected [Request <name>]", sourceLineIndex)
+			} else if !requestNameRe.MatchString(name) {
+				s.setFatalMessage(fmt.Sprintf("Invalid request name: %s, use letters, digits, _, . or -", name), sourceLineIndex)
+			} else if request, exists := blocks.getRequest(name); exists {
+				s.setFatalMessage(fmt.Sprintf("Request %s on line %d redeclared", name, request.headingSourceLineIndex+1), sourceLineIndex)
+			}
+
+			blocks.requests = append(blocks.requests, requestBlock{
+				name:                   name,
+				headingSourceLineIndex: sourceLineIndex,
+			})
+
+			currentRequest = &blocks.requests[len(blocks.requests)-1]
+			continue
+		}
+
+		if currentRequest == nil {
+			blocks.sharedSourceLineIndexes = append(blocks.sharedSourceLineIndexes, sourceLineIndex)
+		} else {
+			currentRequest.sourceLineIndexes = append(currentRequest.sourceLineIndexes, sourceLineIndex)
+		}
+	}
+
+	return blocks
+}
+
+// Returns the template for a file, or the shared part and the
+// selected request for a file with several requests in it
+func getRequestSectionedTemplates(rawTemplateString, filename, requestName string) ([]*sectionedTemplate, string, error) {
+	fileSectionedTemplate := newSectionedTemplate(rawTemplateString, filename)
+
+	blocks := fileSectionedTemplate.getRequestBlocks()
+	if fileSectionedTemplate.hasFatalMessages() {
+		return nil, fileSectionedTemplate.getFatalMessages(), nil
+	}
+
+	if len(blocks.requests) == 0 {
+		if requestName != "" {
+			return nil, "", errors.Errorf("cannot find request %s, template file %s has no [Request <name>] headings", requestName, filename)
+		}
+
+		return []*sectionedTemplate{fileSectionedTemplate}, "", nil
+	}
+
+	if requestName == "" {
+		return nil, "", errors.Errorf("template file %s has several requests, select one with %s%s<name>: %s", filename, filename, requestNameSeparator, strings.Join(blocks.getNames(), ", "))
+	}
+
+	request, exists := blocks.getRequest(requestName)
+	if !exists {
+		return nil, "", errors.Errorf("cannot find request %s in template file %s, available: %s", requestName, filename, strings.Join(blocks.getNames(), ", "))
+	}
+
+	fileSectionedTemplates := []*sectionedTemplate{}
+	if len(blocks.sharedSourceLineIndexes) > 0 {
+		fileSectionedTemplates = append(fileSectionedTemplates, newSectionedTemplateFromLines(fileSectionedTemplate.rawTemplateLines, blocks.sharedSourceLineIndexes, filename))
+	}
+
+	fileSectionedTemplates = append(fileSectionedTemplates, newSectionedTemplateFromLines(fileSectionedTemplate.rawTemplateLines, request.sourceLineIndexes, filename+requestNameSeparator+requestName))
+
+	return fileSectionedTemplates, "", nil
+}
+
+// ListRequests returns the requests in the template files
+// in the form they're selected: file.ain#request-name
+func ListRequests(filenames []string) ([]string, string, error) {
+	requestNames := []string{}
+	listFatals := []string{}
+
+	for _, filename := range filenames {
+		rawTemplateString, err := disk.ReadRawTemplateString(filename, false)
+		if err != nil {
+			return nil, "", err
+		}
+
+		s := newSectionedTemplate(rawTemplateString, filename)
+		blocks := s.getRequestBlocks()
+
+		if s.hasFatalMessages() {
+			listFatals = append(listFatals, s.getFatalMessages())
+			continue
+		}
+
+		for _, name := range blocks.getNames() {
+			requestNames = append(requestNames, filename+requestNameSeparator+name)
+		}
+	}
+
+	return requestNames, strings.Join(listFatals, "\n\n"), nil
+}
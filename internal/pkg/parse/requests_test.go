@@ -0,0 +1,83 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+)
+
+func Test_getRequestSectionedTemplates(t *testing.T) {
+	rawTemplateString := "[Backend]\ncurl\n\n[Request get-user]\n[Host]\n/users/1\n\n[request list-users] # comment\n[Host]\n/users"
+
+	sectionedTemplates, fatal, err := getRequestSectionedTemplates(rawTemplateString, "api.ain", "list-users")
+	if err != nil || fatal != "" {
+		t.Fatalf("Unexpected error: %v %s", err, fatal)
+	}
+
+	if len(sectionedTemplates) != 2 {
+		t.Fatalf("Expected the shared part and the request, got: %d templates", len(sectionedTemplates))
+	}
+
+	if sectionedTemplates[0].filename != "api.ain" || sectionedTemplates[1].filename != "api.ain#list-users" {
+		t.Errorf("Unexpected filenames: %s %s", sectionedTemplates[0].filename, sectionedTemplates[1].filename)
+	}
+
+	sourceLineIndexes := []int{}
+	for _, expandedTemplateLine := range sectionedTemplates[1].expandedTemplateLines {
+		sourceLineIndexes = append(sourceLineIndexes, expandedTemplateLine.sourceLineIndex)
+	}
+
+	if !reflect.DeepEqual(sourceLineIndexes, []int{8, 9}) {
+		t.Errorf("Expected the request to point at lines in the file, got: %v", sourceLineIndexes)
+	}
+}
+
+func Test_getRequestSectionedTemplatesEscapedHeading(t *testing.T) {
+	rawTemplateString := "[Request notes]\n[Body]\n# Notes\n`[Request b]\n[Backend]\ncurl"
+
+	sectionedTemplates, fatal, err := getRequestSectionedTemplates(rawTemplateString, "api.ain", "notes")
+	if err != nil || fatal != "" {
+		t.Fatalf("Unexpected error: %v %s", err, fatal)
+	}
+
+	allSectionRows, fatals := getAllSectionRows(sectionedTemplates, data.NewConfig())
+	if len(fatals) > 0 {
+		t.Fatalf("Unexpected fatals: %v", fatals)
+	}
+
+	// An escaped heading is text in the body, not another request
+	if !reflect.DeepEqual(allSectionRows.body, []string{"[Request b]"}) {
+		t.Errorf("Unexpected body: %q", allSectionRows.body)
+	}
+}
+
+func Test_getRequestSectionedTemplatesBadCases(t *testing.T) {
+	tests := map[string]struct {
+		rawTemplateString string
+		requestName       string
+		expectedError     string
+	}{
+		"No request selected": {
+			rawTemplateString: "[Request a]\n[Request b]",
+			expectedError:     "template file api.ain has several requests, select one with api.ain#<name>: a, b",
+		},
+		"Unknown request": {
+			rawTemplateString: "[Request a]\n[Request b]",
+			requestName:       "c",
+			expectedError:     "cannot find request c in template file api.ain, available: a, b",
+		},
+		"No requests in file": {
+			rawTemplateString: "[Host]\nlocalhost",
+			requestName:       "c",
+			expectedError:     "cannot find request c, template file api.ain has no [Request <name>] headings",
+		},
+	}
+
+	for name, test := range tests {
+		_, _, err := getRequestSectionedTemplates(test.rawTemplateString, "api.ain", test.requestName)
+		if err == nil || err.Error() != test.expectedError {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+		}
+	}
+}
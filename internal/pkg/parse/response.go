@@ -0,0 +1,143 @@
+package parse
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/jonaslu/ain/internal/pkg/data"
+	"github.com/jonaslu/ain/internal/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Selects a part of the response, one of:
+// status
+// header <name>
+// body [<json path>]
+// <json path> (short for body <json path>)
+func parseResponseSelector(tokens []string) (data.ResponseSelector, []string, error) {
+	subject := strings.ToLower(tokens[0])
+
+	switch {
+	case subject == data.StatusSubject:
+		return data.ResponseSelector{Subject: data.StatusSubject}, tokens[1:], nil
+
+	case subject == data.HeaderSubject:
+		if len(tokens) < 2 {
+			return data.ResponseSelector{}, nil, errors.New("Missing header name")
+		}
+
+		return data.ResponseSelector{Subject: data.HeaderSubject, Name: tokens[1]}, tokens[2:], nil
+
+	case subject == data.BodySubject:
+		if len(tokens) > 1 && strings.HasPrefix(tokens[1], "$") {
+			if err := utils.ValidateJsonPath(tokens[1]); err != nil {
+				return data.ResponseSelector{}, nil, err
+			}
+
+			return data.ResponseSelector{Subject: data.BodySubject, Name: tokens[1]}, tokens[2:], nil
+		}
+
+		return data.ResponseSelector{Subject: data.BodySubject}, tokens[1:], nil
+
+	case strings.HasPrefix(subject, "$"):
+		if err := utils.ValidateJsonPath(tokens[0]); err != nil {
+			return data.ResponseSelector{}, nil, err
+		}
+
+		return data.ResponseSelector{Subject: data.BodySubject, Name: tokens[0]}, tokens[1:], nil
+	}
+
+	return data.ResponseSelector{}, nil, errors.Errorf("Unknown response selector: %s, expected status, header, body or a JSON path", tokens[0])
+}
+
+func describeResponseSelector(selector data.ResponseSelector) string {
+	if selector.Name != "" {
+		return selector.Subject + " " + selector.Name
+	}
+
+	return selector.Subject
+}
+
+func jsonValueToString(value interface{}) string {
+	if stringValue, ok := value.(string); ok {
+		return stringValue
+	}
+
+	jsonValue, _ := json.Marshal(value)
+	return string(jsonValue)
+}
+
+// Returns the selected value and if it was found in the response
+func selectResponseValue(selector data.ResponseSelector, response *data.Response) (string, bool, error) {
+	switch selector.Subject {
+	case data.StatusSubject:
+		if response.StatusCode == 0 {
+			return "", false, errors.New("Backend did not report the response status")
+		}
+
+		return strconv.Itoa(response.StatusCode), true, nil
+
+	case data.HeaderSubject:
+		if response.Headers == nil {
+			return "", false, errors.New("Backend did not report the response headers")
+		}
+
+		headerValues := response.Headers.Values(selector.Name)
+		if len(headerValues) == 0 {
+			return "", false, nil
+		}
+
+		return strings.Join(headerValues, ", "), true, nil
+	}
+
+	if selector.Name == "" {
+		return response.Body, true, nil
+	}
+
+	var document interface{}
+	if err := json.Unmarshal([]byte(response.Body), &document); err != nil {
+		return "", false, errors.Wrap(err, "Response body is not valid JSON")
+	}
+
+	value, found, err := utils.LookupJsonPath(document, selector.Name)
+	if err != nil || !found {
+		return "", false, err
+	}
+
+	return jsonValueToString(value), true, nil
+}
+
+// Groups failed assertions or captures per template
+// file in the order the files were first seen
+type responseFailures struct {
+	filenames []string
+	failures  map[string][]string
+}
+
+func (r *responseFailures) add(filename, failure string) {
+	if r.failures == nil {
+		r.failures = map[string][]string{}
+	}
+
+	if _, exists := r.failures[filename]; !exists {
+		r.filenames = append(r.filenames, filename)
+	}
+
+	r.failures[filename] = append(r.failures[filename], failure)
+}
+
+func (r *responseFailures) getReport(heading string) string {
+	reports := []string{}
+
+	for _, filename := range r.filenames {
+		report := heading
+		if len(r.failures[filename]) > 1 {
+			report = report + "s"
+		}
+
+		reports = append(reports, report+" in file: "+filename+"\n"+strings.Join(r.failures[filename], "\n\n"))
+	}
+
+	return strings.Join(reports, "\n\n")
+}
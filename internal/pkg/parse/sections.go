@@ -35,14 +35,24 @@ func (e expandedSourceMarker) getTextContent() string {
 }
 
 const (
-	configSection         = "[config]"
-	hostSection           = "[host]"
-	querySection          = "[query]"
-	headersSection        = "[headers]"
-	methodSection         = "[method]"
-	bodySection           = "[body]"
-	backendSection        = "[backend]"
-	backendOptionsSection = "[backendoptions]"
+	configSection           = "[config]"
+	hostSection             = "[host]"
+	querySection            = "[query]"
+	headersSection          = "[headers]"
+	methodSection           = "[method]"
+	bodySection             = "[body]"
+	backendSection          = "[backend]"
+	backendOptionsSection   = "[backendoptions]"
+	assertSection           = "[assert]"
+	captureSection          = "[capture]"
+	varsSection             = "[vars]"
+	includeSection          = "[include]"
+	authSection             = "[auth]"
+	formSection             = "[form]"
+	multipartSection        = "[multipart]"
+	bodyFileSection         = "[bodyfile]"
+	graphQLSection          = "[graphql]"
+	graphQLVariablesSection = "[graphqlvariables]"
 	// As above, so below
 	// If you add one here then add it to the slice below.
 	// AND IF
@@ -60,6 +70,16 @@ var allSectionHeaders = []string{
 	bodySection,
 	backendSection,
 	backendOptionsSection,
+	assertSection,
+	captureSection,
+	varsSection,
+	includeSection,
+	authSection,
+	formSection,
+	multipartSection,
+	bodyFileSection,
+	graphQLSection,
+	graphQLVariablesSection,
 }
 
 var sectionsAllowingExecutables = []string{
@@ -70,11 +90,37 @@ var sectionsAllowingExecutables = []string{
 	bodySection,
 	backendSection,
 	backendOptionsSection,
+	assertSection,
+	captureSection,
+	authSection,
+	formSection,
+	multipartSection,
+	graphQLSection,
+	graphQLVariablesSection,
+}
+
+// Sections keeping indentation and empty lines between the first and last line
+var sectionsKeepingWhitespace = []string{
+	bodySection,
+	graphQLSection,
+}
+
+// A heading ending in ! (e g [Headers!]) replaces what
+// earlier templates set instead of appending to it
+const replaceSectionModifier = "!"
+
+var sectionsAllowingReplace = []string{
+	hostSection,
+	querySection,
+	headersSection,
+	backendOptionsSection,
 }
 
 type sectionedTemplate struct {
 	// sourceMarker.SourceLineIndex points to the expandedTemplateLines slice
 	sections map[string]*[]sourceMarker
+	// Sections with the ! modifier on the heading
+	replacedSections map[string]bool
 
 	// sourceMarker.SourceLineIndex points to the rawTemplateLines slice
 	expandedTemplateLines []expandedSourceMarker
@@ -82,6 +128,9 @@ type sectionedTemplate struct {
 
 	filename string
 	fatals   []string
+
+	// A [BodyFile] with expand, see newBodyFileSectionedTemplate
+	isBodyFile bool
 }
 
 func (s *sectionedTemplate) getNamedSection(sectionHeader string) *[]sourceMarker {
@@ -98,7 +147,7 @@ func (s *sectionedTemplate) expandTemplateLines(
 ) {
 	newExpandedTemplateLines := []expandedSourceMarker{}
 
-	for _, expandedTemplateLine := range s.expandedTemplateLines {
+	for expandedTemplateLineIndex, expandedTemplateLine := range s.expandedTemplateLines {
 		if expandedTemplateLine.consumed {
 			newExpandedTemplateLines = append(newExpandedTemplateLines, expandedTemplateLine)
 			continue
@@ -107,7 +156,7 @@ func (s *sectionedTemplate) expandTemplateLines(
 		tokens, fatal := tokenize(expandedTemplateLine.content)
 
 		if fatal != "" {
-			s.setFatalMessage(fatal, expandedTemplateLine.sourceLineIndex)
+			s.setFatalMessage(fatal, expandedTemplateLineIndex)
 			continue
 		}
 
@@ -138,7 +187,7 @@ func (s *sectionedTemplate) expandTemplateLines(
 			value, fatal := iterator(token)
 
 			if fatal != "" {
-				s.setFatalMessage(fatal, expandedTemplateLine.sourceLineIndex)
+				s.setFatalMessage(fatal, expandedTemplateLineIndex)
 				continue
 			}
 
@@ -154,7 +203,7 @@ func (s *sectionedTemplate) expandTemplateLines(
 			value = strings.ReplaceAll(value, "\r\n", "\n")
 			newLines := strings.Split(value, "\n")
 
-			valueText, valueComment := splitTextOnComment(newLines[0])
+			valueText, valueComment := s.splitTextOnComment(newLines[0])
 
 			content += valueText
 			fatalContent += valueText
@@ -169,7 +218,7 @@ func (s *sectionedTemplate) expandTemplateLines(
 					expanded:        true,
 				})
 
-				valueText, valueComment := splitTextOnComment(newLine)
+				valueText, valueComment := s.splitTextOnComment(newLine)
 
 				content = valueText
 				fatalContent = valueText
@@ -202,10 +251,21 @@ func (s *sectionedTemplate) expandTemplateLines(
 func newSectionedTemplate(rawTemplateString, filename string) *sectionedTemplate {
 	rawTemplateLines := strings.Split(strings.ReplaceAll(rawTemplateString, "\r\n", "\n"), "\n")
 
+	sourceLineIndexes := make([]int, len(rawTemplateLines))
+	for i := range rawTemplateLines {
+		sourceLineIndexes[i] = i
+	}
+
+	return newSectionedTemplateFromLines(rawTemplateLines, sourceLineIndexes, filename)
+}
+
+// Only the given lines are part of the template, fatals
+// still show the context of the whole file
+func newSectionedTemplateFromLines(rawTemplateLines []string, sourceLineIndexes []int, filename string) *sectionedTemplate {
 	expandedTemplateLines := []expandedSourceMarker{}
 
-	for sourceIndex, rawTemplateLine := range rawTemplateLines {
-		content, comment := splitTextOnComment(rawTemplateLine)
+	for _, sourceIndex := range sourceLineIndexes {
+		content, comment := splitTextOnComment(rawTemplateLines[sourceIndex])
 
 		expandedTemplateLines = append(expandedTemplateLines, expandedSourceMarker{
 			content:      content,
@@ -219,6 +279,7 @@ func newSectionedTemplate(rawTemplateString, filename string) *sectionedTemplate
 
 	sectionedTemplate := sectionedTemplate{
 		sections:              map[string]*[]sourceMarker{},
+		replacedSections:      map[string]bool{},
 		expandedTemplateLines: expandedTemplateLines,
 		rawTemplateLines:      rawTemplateLines,
 		filename:              filename,
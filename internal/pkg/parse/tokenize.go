@@ -55,6 +55,20 @@ func splitTextOnComment(input string) (string, string) {
 	return currentContent, ""
 }
 
+// EscapeTemplateText escapes comments, variables, executables and
+// section headings so the text is read back as is from a template
+func EscapeTemplateText(text string) string {
+	for _, tokenPrefix := range []string{commentPrefix, envVarPrefix, executablePrefix} {
+		text = strings.ReplaceAll(text, tokenPrefix, "`"+tokenPrefix)
+	}
+
+	if trimmedText := strings.TrimSpace(text); isEscapableHeading(trimmedText) {
+		text = strings.Replace(text, trimmedText, "`"+trimmedText, 1)
+	}
+
+	return text
+}
+
 func unescapeEnvVars(content string, hasNextToken bool) string {
 	content = strings.ReplaceAll(content, "`"+envVarPrefix, envVarPrefix)
 
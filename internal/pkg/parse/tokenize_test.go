@@ -165,6 +165,14 @@ func Test_tokenizeEnvVarsGoodCases(t *testing.T) {
 				fatalContent: "${VAR1`}}",
 			}},
 		},
+		"Escaped end bracket in default value": {
+			input: "${JSON:-{\"a\": 1`}}",
+			expectedTokens: []token{{
+				tokenType:    envVarToken,
+				content:      "JSON:-{\"a\": 1}",
+				fatalContent: "${JSON:-{\"a\": 1`}}",
+			}},
+		},
 		"Escaped backtick last in envvar": {
 			input: "${ENV\\`}",
 			expectedTokens: []token{{
@@ -330,3 +338,24 @@ func Test_tokenizeExecutablesBadCases(t *testing.T) {
 		}
 	}
 }
+
+func Test_EscapeTemplateText(t *testing.T) {
+	tests := map[string]struct {
+		text         string
+		expectedText string
+	}{
+		"Comment":      {text: "color: #fff", expectedText: "color: `#fff"},
+		"Env var":      {text: `{"user": "${USER}"}`, expectedText: `{"user": "` + "`" + `${USER}"}`},
+		"Executable":   {text: "echo $(date)", expectedText: "echo `$(date)"},
+		"Heading":      {text: "  [Body]", expectedText: "  `[Body]"},
+		"Request":      {text: "[Request notes]", expectedText: "`[Request notes]"},
+		"Not heading":  {text: "[1, 2]", expectedText: "[1, 2]"},
+		"Plain dollar": {text: "$5", expectedText: "$5"},
+	}
+
+	for name, test := range tests {
+		if text := EscapeTemplateText(test.text); text != test.expectedText {
+			t.Errorf("Test: %s. Unexpected text: %s", name, text)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package parse
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Variables declared in [Vars] sections. The environment
+// (and thus --vars and any .env-file) always takes precedence.
+type templateVars struct {
+	values map[string]string
+
+	// Names given to executables in [Vars] values
+	executableNames map[string]bool
+}
+
+func newTemplateVars() templateVars {
+	return templateVars{
+		values:          map[string]string{},
+		executableNames: map[string]bool{},
+	}
+}
+
+func (v templateVars) lookup(varName string) (string, bool) {
+	if value, exists := os.LookupEnv(varName); exists {
+		return value, true
+	}
+
+	value, exists := v.values[varName]
+	return value, exists
+}
+
+func (v templateVars) keys() []string {
+	keys := []string{}
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Named after the variable, with a suffix if the
+// variable is redeclared or has several executables
+func (v templateVars) newExecutableName(varName string) string {
+	executableName := varName
+	for suffix := 2; v.executableNames[executableName]; suffix++ {
+		executableName = varName + "." + strconv.Itoa(suffix)
+	}
+
+	v.executableNames[executableName] = true
+
+	return executableName
+}
+
+// Names the executables in the value so they're only run
+// once no matter how many times the variable is used
+func (v templateVars) nameExecutables(varName, value string) string {
+	tokens, fatal := tokenizeExecutables(value)
+	if fatal != "" {
+		// Reported where the variable is used
+		return value
+	}
+
+	namedValue := ""
+	for _, token := range tokens {
+		if token.tokenType != executableToken || token.content == "" || strings.HasPrefix(token.content, executableNamePrefix) {
+			namedValue += token.fatalContent
+			continue
+		}
+
+		namedValue += executablePrefix + executableNamePrefix + v.newExecutableName(varName) + " " + strings.TrimPrefix(token.fatalContent, executablePrefix)
+	}
+
+	return namedValue
+}
+
+func expandVarValue(varValue string, vars templateVars) (string, string) {
+	tokens, fatal := tokenizeEnvVars(varValue)
+	if fatal != "" {
+		return "", fatal
+	}
+
+	value := ""
+	for _, token := range tokens {
+		if token.tokenType == textToken {
+			value += token.content
+			continue
+		}
+
+		envVarValue, fatal := expandEnvVar(token.content, vars)
+		if fatal != "" {
+			return "", fatal
+		}
+
+		value += envVarValue
+	}
+
+	return value, ""
+}
+
+// Declares the variables in the [Vars] section in order, so
+// a variable can use the ones declared on the lines before it.
+// Must run before any other expansion since the fatals point
+// into the unexpanded template lines.
+func (s *sectionedTemplate) setVars(vars templateVars) {
+	if s.setCapturedSections(varsSection); s.hasFatalMessages() {
+		return
+	}
+
+	for _, varsSourceMarker := range *s.getNamedSection(varsSection) {
+		sourceLineIndex := varsSourceMarker.sourceLineIndex
+
+		// Keep any escaped comments, the value is escaped
+		// the same way as the line it's inserted into
+		lineContents := strings.TrimSpace(s.expandedTemplateLines[sourceLineIndex].content)
+
+		varName, varValue, found := strings.Cut(lineContents, "=")
+		if !found {
+			s.setFatalMessage("Missing = in variable, expected VARIABLE=value", sourceLineIndex)
+			continue
+		}
+
+		varName = strings.TrimSpace(varName)
+		if !varNameRe.MatchString(varName) {
+			s.setFatalMessage(fmt.Sprintf("Invalid variable name: %s", varName), sourceLineIndex)
+			continue
+		}
+
+		value, fatal := expandVarValue(strings.TrimSpace(varValue), vars)
+		if fatal != "" {
+			s.setFatalMessage(fatal, sourceLineIndex)
+			continue
+		}
+
+		vars.values[varName] = vars.nameExecutables(varName, value)
+	}
+}
@@ -0,0 +1,92 @@
+package parse
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_substituteEnvVarsWithVars(t *testing.T) {
+	os.Setenv("VARS_FROM_ENV", "env")
+	os.Unsetenv("VARS_BASE")
+	os.Unsetenv("VARS_ID")
+
+	vars := newTemplateVars()
+
+	first := newSectionedTemplate("[Vars]\nVARS_FROM_ENV=template\nVARS_BASE = https://api.example.com\nVARS_ID=${VARS_BASE}/42 `# not a comment\n\n[Host]\n${VARS_ID}", "first.ain")
+	if first.substituteEnvVars(vars); first.hasFatalMessages() {
+		t.Fatalf("Got unexpected fatals, %s ", first.getFatalMessages())
+	}
+
+	second := newSectionedTemplate("[Host]\n${VARS_ID} ${VARS_FROM_ENV}", "second.ain")
+	if second.substituteEnvVars(vars); second.hasFatalMessages() {
+		t.Fatalf("Got unexpected fatals, %s ", second.getFatalMessages())
+	}
+
+	if content := first.expandedTemplateLines[6].getTextContent(); content != "https://api.example.com/42 # not a comment" {
+		t.Errorf("Unexpected content in declaring template: %s", content)
+	}
+
+	if content := second.expandedTemplateLines[1].getTextContent(); content != "https://api.example.com/42 # not a comment env" {
+		t.Errorf("Unexpected content in later template: %s", content)
+	}
+}
+
+func Test_setVarsNamesExecutables(t *testing.T) {
+	os.Unsetenv("VARS_TOKEN")
+
+	vars := newTemplateVars()
+
+	first := newSectionedTemplate("[Vars]\nVARS_TOKEN=$(echo 1) $(@other) `$(echo 2)", "first.ain")
+	second := newSectionedTemplate("[Vars]\nVARS_TOKEN=$(echo 3)", "second.ain")
+
+	first.substituteEnvVars(vars)
+	if value := vars.values["VARS_TOKEN"]; value != "$(@VARS_TOKEN echo 1) $(@other) `$(echo 2)" {
+		t.Errorf("Unexpected value: %s", value)
+	}
+
+	second.substituteEnvVars(vars)
+	if value := vars.values["VARS_TOKEN"]; value != "$(@VARS_TOKEN.2 echo 3)" {
+		t.Errorf("Unexpected value after redeclaring: %s", value)
+	}
+}
+
+func Test_setVarsBadCases(t *testing.T) {
+	os.Unsetenv("VARS_MISSING")
+
+	tests := map[string]struct {
+		input                string
+		expectedFatalMessage string
+	}{
+		"Missing equals": {
+			input:                "[Vars]\nVARS_ID 42",
+			expectedFatalMessage: "Missing = in variable, expected VARIABLE=value on line 2",
+		},
+		"Invalid variable name": {
+			input:                "[Vars]\nVARS ID=42",
+			expectedFatalMessage: "Invalid variable name: VARS ID on line 2",
+		},
+		"Missing variable in value": {
+			input:                "[Vars]\nVARS_ID=42\nVARS_URL=${VARS_MISSING}",
+			expectedFatalMessage: "Cannot find value for variable VARS_MISSING on line 3",
+		},
+		"Used before declared": {
+			input:                "[Vars]\nVARS_URL=${VARS_LATER}\nVARS_LATER=1",
+			expectedFatalMessage: "Cannot find value for variable VARS_LATER on line 2",
+		},
+	}
+
+	for name, test := range tests {
+		s := newSectionedTemplate(test.input, "")
+		s.substituteEnvVars(newTemplateVars())
+
+		if len(s.fatals) != 1 {
+			t.Errorf("Test: %s. Wrong number of fatals: %v", name, s.fatals)
+			continue
+		}
+
+		if !strings.HasPrefix(s.fatals[0], test.expectedFatalMessage) {
+			t.Errorf("Test: %s. Unexpected error message: %s", name, s.fatals[0])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A small subset of JSONPath: $, .key, ["key"], ['key'] and [index].
+// Negative indexes count from the end of an array.
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+const jsonPathRoot = "$"
+
+func parseJsonPath(path string) ([]jsonPathSegment, error) {
+	if !strings.HasPrefix(path, jsonPathRoot) {
+		return nil, errors.Errorf("JSON path must start with %s: %s", jsonPathRoot, path)
+	}
+
+	segments := []jsonPathSegment{}
+	rest := path[len(jsonPathRoot):]
+
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			keyEnd := strings.IndexAny(rest[1:], ".[")
+			if keyEnd == -1 {
+				keyEnd = len(rest) - 1
+			}
+
+			key := rest[1 : keyEnd+1]
+			if key == "" {
+				return nil, errors.Errorf("Empty key in JSON path: %s", path)
+			}
+
+			segments = append(segments, jsonPathSegment{key: key})
+			rest = rest[keyEnd+1:]
+
+		case '[':
+			closingBracket := strings.Index(rest, "]")
+			if closingBracket == -1 {
+				return nil, errors.Errorf("Missing closing bracket in JSON path: %s", path)
+			}
+
+			bracketContent := rest[1:closingBracket]
+			rest = rest[closingBracket+1:]
+
+			if len(bracketContent) >= 2 &&
+				(bracketContent[0] == '"' || bracketContent[0] == '\'') &&
+				bracketContent[len(bracketContent)-1] == bracketContent[0] {
+				segments = append(segments, jsonPathSegment{key: bracketContent[1 : len(bracketContent)-1]})
+				continue
+			}
+
+			index, err := strconv.Atoi(bracketContent)
+			if err != nil {
+				return nil, errors.Errorf("Invalid index %s in JSON path: %s", bracketContent, path)
+			}
+
+			segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+
+		default:
+			return nil, errors.Errorf("Unexpected %c in JSON path: %s", rest[0], path)
+		}
+	}
+
+	return segments, nil
+}
+
+func ValidateJsonPath(path string) error {
+	_, err := parseJsonPath(path)
+	return err
+}
+
+// LookupJsonPath walks a document as returned by encoding/json
+// and returns the value and if it was found
+func LookupJsonPath(document interface{}, path string) (interface{}, bool, error) {
+	segments, err := parseJsonPath(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current := document
+	for _, segment := range segments {
+		if segment.isIndex {
+			array, ok := current.([]interface{})
+			if !ok {
+				return nil, false, nil
+			}
+
+			index := segment.index
+			if index < 0 {
+				index = len(array) + index
+			}
+
+			if index < 0 || index >= len(array) {
+				return nil, false, nil
+			}
+
+			current = array[index]
+			continue
+		}
+
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false, nil
+		}
+
+		value, exists := object[segment.key]
+		if !exists {
+			return nil, false, nil
+		}
+
+		current = value
+	}
+
+	return current, true, nil
+}
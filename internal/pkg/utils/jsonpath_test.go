@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLookupJsonPathGoodCases(t *testing.T) {
+	var document interface{}
+	json.Unmarshal([]byte(`{
+		"id": 1,
+		"user": { "name": "goat", "tags": ["a", "b", "c"] },
+		"dotted.key": true,
+		"items": [{ "price": 10 }, { "price": 20 }]
+	}`), &document)
+
+	tests := map[string]struct {
+		path          string
+		expectedValue interface{}
+		expectedFound bool
+	}{
+		"Root": {
+			path:          "$.id",
+			expectedValue: float64(1),
+			expectedFound: true,
+		},
+		"Nested key": {
+			path:          "$.user.name",
+			expectedValue: "goat",
+			expectedFound: true,
+		},
+		"Index": {
+			path:          "$.user.tags[1]",
+			expectedValue: "b",
+			expectedFound: true,
+		},
+		"Negative index": {
+			path:          "$.user.tags[-1]",
+			expectedValue: "c",
+			expectedFound: true,
+		},
+		"Quoted key": {
+			path:          `$["dotted.key"]`,
+			expectedValue: true,
+			expectedFound: true,
+		},
+		"Key in array element": {
+			path:          "$.items[1].price",
+			expectedValue: float64(20),
+			expectedFound: true,
+		},
+		"Missing key": {
+			path:          "$.user.missing",
+			expectedFound: false,
+		},
+		"Index out of range": {
+			path:          "$.items[2]",
+			expectedFound: false,
+		},
+		"Key on array": {
+			path:          "$.items.price",
+			expectedFound: false,
+		},
+	}
+
+	for name, test := range tests {
+		value, found, err := LookupJsonPath(document, test.path)
+		if err != nil {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+			continue
+		}
+
+		if found != test.expectedFound || !reflect.DeepEqual(value, test.expectedValue) {
+			t.Errorf("Test: %s. Expected %v (%v), got: %v (%v)", name, test.expectedValue, test.expectedFound, value, found)
+		}
+	}
+}
+
+func TestValidateJsonPathBadCases(t *testing.T) {
+	tests := map[string]struct {
+		path                 string
+		expectedErrorMessage string
+	}{
+		"Missing root": {
+			path:                 "id",
+			expectedErrorMessage: "JSON path must start with $: id",
+		},
+		"Empty key": {
+			path:                 "$..id",
+			expectedErrorMessage: "Empty key in JSON path: $..id",
+		},
+		"Missing closing bracket": {
+			path:                 "$.items[1",
+			expectedErrorMessage: "Missing closing bracket in JSON path: $.items[1",
+		},
+		"Invalid index": {
+			path:                 "$.items[one]",
+			expectedErrorMessage: "Invalid index one in JSON path: $.items[one]",
+		},
+	}
+
+	for name, test := range tests {
+		err := ValidateJsonPath(test.path)
+		if err == nil || err.Error() != test.expectedErrorMessage {
+			t.Errorf("Test: %s. Unexpected error: %v", name, err)
+		}
+	}
+}
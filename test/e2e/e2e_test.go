@@ -167,6 +167,11 @@ func readTestFiles(templateFolder string) ([]string, error) {
 	testFilePaths := []string{}
 
 	for _, file := range files {
+		// Base templates (_base.ain, .ain/base.ain) are picked up by the tests below them
+		if strings.HasPrefix(file.Name(), "_") || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
 		if file.IsDir() {
 			subFolderPath := templateFolder + "/" + file.Name()
 			subFolderDirs, err := readTestFiles(subFolderPath)